@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-goll/aigit/internal/ai"
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/git"
+)
+
+// candidatesNoteRef is the notes ref that records which candidate was
+// committed; view it with `git log --notes=aigit`.
+const candidatesNoteRef = "aigit"
+
+// pickAction is what the user chose to do with the candidates.
+type pickAction int
+
+const (
+	pickAbort pickAction = iota
+	pickCommit
+	pickEdit
+	pickRefine
+	pickRegenerate
+)
+
+// commitCandidates generates several messages, lets the user choose one and
+// commits it. With --yes the first candidate is committed.
+func commitCandidates(cfg *config.Config, client ai.Client, diff string) error {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		messages, err := generateCandidates(cfg, client, diff)
+		if err != nil {
+			return fmt.Errorf("failed to generate commit messages: %w", err)
+		}
+
+		if autoCommit {
+			return commitCandidate(messages[0], 1, len(messages), false)
+		}
+
+		action, choice := pickCandidate(reader, messages)
+		candidate := ""
+		if choice > 0 {
+			candidate = messages[choice-1]
+		}
+
+		message := candidate
+		switch action {
+		case pickRegenerate:
+			continue
+		case pickAbort:
+			fmt.Println("Commit aborted.")
+			return nil
+		case pickEdit:
+			if message, err = editMessage(candidate); err != nil {
+				return err
+			}
+		case pickRefine:
+			history := []ai.Message{{Role: ai.RoleAssistant, Content: candidate}}
+			history, _ = refineMessage(cfg, client, reader, diff, history)
+			message, err = confirmMessage(cfg, client, reader, diff, history)
+			if err != nil || message == "" {
+				return err
+			}
+		}
+
+		if message == "" {
+			fmt.Println("Empty message, commit aborted.")
+			return nil
+		}
+		return commitCandidate(message, choice, len(messages), message != candidate)
+	}
+}
+
+func generateCandidates(cfg *config.Config, client ai.Client, diff string) ([]string, error) {
+	fmt.Printf("Generating %d commit message candidates...\n", candidates)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutOr(60*time.Second))
+	defer cancel()
+
+	messages, err := ai.GenerateCommitMessages(ctx, client, diff, cfg.Language, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no commit message generated")
+	}
+
+	for i, message := range messages {
+		fmt.Printf("\n--- Candidate %d ---\n", i+1)
+		fmt.Println(message)
+	}
+	fmt.Println("-------------------")
+	return messages, nil
+}
+
+// pickCandidate asks what to do with the candidates and returns the
+// action with the 1-based index of the chosen message, if any.
+func pickCandidate(reader *bufio.Reader, messages []string) (pickAction, int) {
+	for {
+		fmt.Printf("\nPick a message [1-%d], g(enerate again), e(dit) <number>, r(efine) <number> or n(o): ", len(messages))
+		answer, err := reader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if err != nil && answer == "" {
+			return pickAbort, 0
+		}
+
+		switch answer {
+		case "g", "generate":
+			return pickRegenerate, 0
+		case "n", "no", "q", "quit":
+			return pickAbort, 0
+		}
+
+		action := pickCommit
+		for _, p := range []struct {
+			prefix string
+			action pickAction
+		}{{"edit", pickEdit}, {"e", pickEdit}, {"refine", pickRefine}, {"r", pickRefine}} {
+			if rest, ok := strings.CutPrefix(answer, p.prefix); ok {
+				action, answer = p.action, strings.TrimSpace(rest)
+				break
+			}
+		}
+		i, err := strconv.Atoi(answer)
+		if err != nil || i < 1 || i > len(messages) {
+			fmt.Printf("Please enter a number from 1 to %d.\n", len(messages))
+			continue
+		}
+		return action, i
+	}
+}
+
+// commitCandidate commits message and records which of the total
+// candidates it was, so the team can see which style gets picked. edited
+// notes that the message was changed by hand or refined before committing.
+func commitCandidate(message string, choice, total int, edited bool) error {
+	if err := doCommit(message); err != nil {
+		return err
+	}
+
+	note := fmt.Sprintf("Candidate: %d/%d", choice, total)
+	if edited {
+		note += "\nEdited: yes"
+	}
+	if err := git.AddNote(candidatesNoteRef, "HEAD", note); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not record the chosen candidate: %v\n", err)
+	}
+	return nil
+}
@@ -15,8 +15,13 @@ import (
 )
 
 var (
-	autoCommit bool
-	stageAll   bool
+	autoCommit  bool
+	stageAll    bool
+	maxTokensIn int
+	candidates  int
+	splitCommit bool
+	hookMsgFile string
+	hookSource  string
 )
 
 var commitCmd = &cobra.Command{
@@ -29,14 +34,31 @@ var commitCmd = &cobra.Command{
 func init() {
 	commitCmd.Flags().BoolVarP(&autoCommit, "yes", "y", false, "Auto commit without confirmation")
 	commitCmd.Flags().BoolVarP(&stageAll, "all", "a", false, "Stage all changes before commit")
+	commitCmd.Flags().IntVarP(&candidates, "candidates", "n", 1, fmt.Sprintf("Generate several messages and pick one (up to %d)", ai.MaxCandidates))
+	commitCmd.Flags().BoolVar(&splitCommit, "split", false, "Split staged changes into several commits, grouping related hunks")
+	commitCmd.Flags().StringVar(&hookMsgFile, "hook-msg-file", "", "Write a draft message into this file (used by the prepare-commit-msg hook)")
+	commitCmd.Flags().StringVar(&hookSource, "source", "", "Message source git passes to the prepare-commit-msg hook")
+	commitCmd.Flags().IntVar(&maxTokensIn, "max-tokens-in", 0, "Input token budget; larger diffs are split and summarised (default: from model context window)")
 }
 
 func runCommit(cmd *cobra.Command, args []string) error {
 	if !git.IsGitRepo() {
 		return fmt.Errorf("not a git repository")
 	}
+	if hookMsgFile != "" {
+		return draftHookMessage(cmd, hookMsgFile, hookSource)
+	}
+	if cmd.Flags().Changed("source") {
+		return fmt.Errorf("--source requires --hook-msg-file")
+	}
+	if candidates < 1 || candidates > ai.MaxCandidates {
+		return fmt.Errorf("--candidates must be between 1 and %d", ai.MaxCandidates)
+	}
+	if splitCommit && candidates > 1 {
+		return fmt.Errorf("--split cannot be combined with --candidates")
+	}
 
-	cfg, err := config.Load()
+	cfg, err := loadConfig(cmd, config.TaskCommit)
 	if err != nil {
 		return err
 	}
@@ -57,61 +79,165 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no staged changes to commit")
 	}
 
+	ignore, err := loadIgnore(cfg)
+	if err != nil {
+		return err
+	}
+
 	files, _ := git.GetStagedFiles()
 	if len(files) > 0 {
 		fmt.Println("Staged files:")
 		for _, f := range files {
-			fmt.Printf("  • %s\n", f)
+			if ignore.Match(f) {
+				fmt.Printf("  • %s (excluded from AI input)\n", f)
+			} else {
+				fmt.Printf("  • %s\n", f)
+			}
 		}
 		fmt.Println()
 	}
 
-	fmt.Println("Generating commit message...")
+	diff, err = redactDiff(cfg, ignore.FilterDiff(diff))
+	if err != nil {
+		return err
+	}
 
-	client, err := ai.NewClient(cfg)
+	prompts, err := loadPrompts(cfg, config.TaskCommit, diff)
+	if err != nil {
+		return err
+	}
+
+	client, err := ai.NewClient(cfg, prompts)
 	if err != nil {
 		return fmt.Errorf("failed to create AI client: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	if splitCommit {
+		return commitSplit(cfg, client, ignore, diff)
+	}
+	if candidates > 1 {
+		return commitCandidates(cfg, client, diff)
+	}
+	return commitSingle(cfg, client, diff)
+}
+
+// commitSingle generates one message for diff and commits it once the user
+// confirms it.
+func commitSingle(cfg *config.Config, client ai.Client, diff string) error {
+	fmt.Println("Generating commit message...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutOr(60*time.Second))
 	defer cancel()
 
-	message, err := client.GenerateCommitMessage(ctx, diff, cfg.Language)
+	message, err := generateCommitMessage(ctx, client, diff, cfg.Language)
 	if err != nil {
 		return fmt.Errorf("failed to generate commit message: %w", err)
 	}
 
-	message = strings.TrimSpace(message)
-
-	fmt.Println("\n--- Generated Commit Message ---")
-	fmt.Println(message)
-	fmt.Println("--------------------------------")
-
 	if autoCommit {
 		return doCommit(message)
 	}
 
-	fmt.Print("\nCommit with this message? [Y/n/e(dit)]: ")
 	reader := bufio.NewReader(os.Stdin)
-	answer, _ := reader.ReadString('\n')
-	answer = strings.ToLower(strings.TrimSpace(answer))
+	message, err = confirmMessage(cfg, client, reader, diff, []ai.Message{{Role: ai.RoleAssistant, Content: message}})
+	if err != nil || message == "" {
+		return err
+	}
+	return doCommit(message)
+}
 
-	switch answer {
-	case "", "y", "yes":
-		return doCommit(message)
-	case "e", "edit":
-		fmt.Print("Enter new message: ")
-		newMsg, _ := reader.ReadString('\n')
-		newMsg = strings.TrimSpace(newMsg)
-		if newMsg != "" {
-			return doCommit(newMsg)
+// confirmMessage asks whether to commit the last message of history, the
+// conversation that produced it. The user can edit the message
+// in their editor or refine it with an instruction, which continues the
+// conversation. It returns "" when the user aborts.
+func confirmMessage(cfg *config.Config, client ai.Client, reader *bufio.Reader, diff string, history []ai.Message) (string, error) {
+	message := history[len(history)-1].Content
+	for {
+		fmt.Print("\nCommit with this message? [Y/n/e(dit)/r(efine)]: ")
+		answer, _ := reader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+
+		switch answer {
+		case "", "y", "yes":
+			return message, nil
+		case "e", "edit":
+			edited, err := editMessage(message)
+			if err != nil {
+				return "", err
+			}
+			if edited == "" {
+				fmt.Println("Empty message, commit aborted.")
+			}
+			return edited, nil
+		case "r", "refine":
+			refined, ok := refineMessage(cfg, client, reader, diff, history)
+			if ok {
+				history = refined
+				message = history[len(history)-1].Content
+			}
+		default:
+			fmt.Println("Commit aborted.")
+			return "", nil
+		}
+	}
+}
+
+// refineMessage asks for an instruction and returns history extended with
+// it and the revised message. Failures are reported and leave the current
+// message in place.
+func refineMessage(cfg *config.Config, client ai.Client, reader *bufio.Reader, diff string, history []ai.Message) ([]ai.Message, bool) {
+	fmt.Print("How should it change? ")
+	instruction, _ := reader.ReadString('\n')
+	instruction = strings.TrimSpace(instruction)
+	if instruction == "" {
+		return history, false
+	}
+
+	fmt.Println("Refining commit message...")
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutOr(60*time.Second))
+	defer cancel()
+
+	refined, err := client.RefineCommitMessage(ctx, diff, cfg.Language, history, instruction)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Failed to refine commit message: %v\n", err)
+		return history, false
+	}
+	refined = strings.TrimSpace(refined)
+
+	fmt.Println("\n--- Refined Commit Message ---")
+	fmt.Println(refined)
+	fmt.Println("------------------------------")
+
+	return append(history,
+		ai.Message{Role: ai.RoleUser, Content: instruction},
+		ai.Message{Role: ai.RoleAssistant, Content: refined},
+	), true
+}
+
+// generateCommitMessage prints the generated message inside the usual
+// frame, streaming it token by token when the client and terminal allow.
+func generateCommitMessage(ctx context.Context, client ai.Client, diff, language string) (string, error) {
+	streamer, ok := client.(ai.Streamer)
+	if !ok || !stdoutIsTerminal() {
+		message, err := client.GenerateCommitMessage(ctx, diff, language)
+		if err != nil {
+			return "", err
 		}
-		fmt.Println("Empty message, commit aborted.")
-		return nil
-	default:
-		fmt.Println("Commit aborted.")
-		return nil
+		message = strings.TrimSpace(message)
+		fmt.Println("\n--- Generated Commit Message ---")
+		fmt.Println(message)
+		fmt.Println("--------------------------------")
+		return message, nil
+	}
+
+	fmt.Println("\n--- Generated Commit Message ---")
+	message, err := streamer.StreamCommitMessage(ctx, diff, language, printToken)
+	fmt.Println()
+	if err != nil {
+		return "", err
 	}
+	fmt.Println("--------------------------------")
+	return strings.TrimSpace(message), nil
 }
 
 func doCommit(message string) error {
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/go-goll/aigit/internal/git"
+)
+
+// editMessage opens message in the user's git editor, with the staged files
+// and diffstat listed below it as comments, and returns the saved text with
+// comments stripped the way `git commit` does. An empty result means the
+// user aborted.
+func editMessage(message string) (string, error) {
+	dir, err := os.MkdirTemp("", "aigit-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Named like git's own file so editors recognise it as a commit message.
+	path := filepath.Join(dir, "COMMIT_EDITMSG")
+	comment := git.CommentChar()
+	if err := os.WriteFile(path, []byte(message+"\n\n"+editTemplate(comment)), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write message file: %w", err)
+	}
+
+	if err := runEditor(path); err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read message file: %w", err)
+	}
+	return git.CleanupMessage(string(edited), comment), nil
+}
+
+// editTemplate is the commented help text below the message, as git shows
+// it, followed by the staged files and their diffstat.
+func editTemplate(comment string) string {
+	var b strings.Builder
+	line := func(text string) {
+		if text == "" {
+			b.WriteString(comment + "\n")
+		} else {
+			b.WriteString(comment + " " + text + "\n")
+		}
+	}
+
+	line("Please enter the commit message for your changes. Lines starting")
+	line(fmt.Sprintf("with '%s' will be ignored, and an empty message aborts the commit.", comment))
+
+	if files, _ := git.GetStagedFiles(); len(files) > 0 {
+		line("")
+		line("Changes to be committed:")
+		for _, f := range files {
+			b.WriteString(comment + "\t" + f + "\n")
+		}
+	}
+	if stat, _ := git.GetStagedStat(); stat != "" {
+		line("")
+		for _, s := range strings.Split(strings.TrimRight(stat, "\n"), "\n") {
+			line(s)
+		}
+	}
+	return b.String()
+}
+
+// runEditor runs the editor on path attached to the terminal. Like git, the
+// editor setting is passed to the shell so it may include arguments, such as
+// "code --wait".
+func runEditor(path string) error {
+	editor := git.Editor()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", editor+` "`+path+`"`)
+	} else {
+		cmd = exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-goll/aigit/internal/ai"
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/git"
+)
+
+// hookTimeout bounds how long the prepare-commit-msg hook keeps git
+// waiting. A shorter configured timeout applies; a longer one, meant for
+// interactive commands, does not.
+const hookTimeout = 30 * time.Second
+
+// draftHookMessage implements the prepare-commit-msg hook: it writes a
+// generated message at the top of the message file git is about to open in
+// the editor. Only plain commits and commits from a template are drafted;
+// messages from -m or -F, merges, squashes and amends are left alone. Any
+// failure leaves the file untouched so git carries on with its default.
+func draftHookMessage(cmd *cobra.Command, path, source string) error {
+	if source != "" && source != "template" {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	// A plain commit can still come with a prepared message, for example
+	// when resolving a conflicted cherry-pick; keep it.
+	if source == "" && git.CleanupMessage(string(content), git.CommentChar()) != "" {
+		return nil
+	}
+
+	// Retry warnings would only clutter the commit; failures fall back to
+	// git's message anyway.
+	ai.SetLogOutput(io.Discard)
+	message, err := hookMessage(cmd)
+	if err != nil || message == "" {
+		return nil
+	}
+	os.WriteFile(path, []byte(message+"\n"+string(content)), 0644)
+	return nil
+}
+
+func hookMessage(cmd *cobra.Command) (string, error) {
+	cfg, err := loadConfig(cmd, config.TaskCommit)
+	if err != nil {
+		return "", err
+	}
+
+	diff, err := git.GetStagedDiff()
+	if err != nil || diff == "" {
+		return "", err
+	}
+	ignore, err := loadIgnore(cfg)
+	if err != nil {
+		return "", err
+	}
+	diff, err = redactDiff(cfg, ignore.FilterDiff(diff))
+	if err != nil {
+		return "", err
+	}
+	prompts, err := loadPrompts(cfg, config.TaskCommit, diff)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := ai.NewClient(cfg, prompts)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintln(os.Stderr, "aigit: drafting commit message...")
+	ctx, cancel := context.WithTimeout(context.Background(), min(cfg.TimeoutOr(hookTimeout), hookTimeout))
+	defer cancel()
+	message, err := client.GenerateCommitMessage(ctx, diff, cfg.Language)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(message), nil
+}
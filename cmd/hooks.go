@@ -1,127 +1,316 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/go-goll/aigit/internal/git"
+	"github.com/go-goll/aigit/internal/hooks"
 )
 
 var hooksCmd = &cobra.Command{
 	Use:   "hooks",
 	Short: "Manage git hooks",
-	Long:  `Install or uninstall git hooks for automatic code review on commit.`,
+	Long: `Install, uninstall or inspect git hooks:
+
+  pre-commit          review staged changes before each commit (default)
+  prepare-commit-msg  draft the message when 'git commit' opens the editor
+  commit-msg          check the message against the configured conventions
+
+aigit adds a marked block to the hook in the directory git runs hooks from
+(core.hooksPath if set), so existing hooks keep running. When husky,
+pre-commit or lefthook manages the hooks, the configuration to add is
+printed instead.`,
 }
 
 var installHooksCmd = &cobra.Command{
 	Use:   "install",
-	Short: "Install pre-commit hook for auto review",
+	Short: "Install a git hook (pre-commit review by default)",
 	RunE:  runInstallHooks,
 }
 
 var uninstallHooksCmd = &cobra.Command{
 	Use:   "uninstall",
-	Short: "Uninstall pre-commit hook",
+	Short: "Uninstall a git hook installed by aigit",
 	RunE:  runUninstallHooks,
 }
 
+var statusHooksCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which aigit hooks are installed",
+	RunE:  runHooksStatus,
+}
+
+var (
+	hookType  string
+	hookForce bool
+)
+
 func init() {
+	installHooksCmd.Flags().StringVar(&hookType, "type", "pre-commit", "Hook to install: pre-commit, prepare-commit-msg, commit-msg")
+	installHooksCmd.Flags().BoolVar(&hookForce, "force", false, "Write the hook even when a hook manager is detected")
+	uninstallHooksCmd.Flags().StringVar(&hookType, "type", "pre-commit", "Hook to uninstall: pre-commit, prepare-commit-msg, commit-msg")
 	hooksCmd.AddCommand(installHooksCmd)
 	hooksCmd.AddCommand(uninstallHooksCmd)
+	hooksCmd.AddCommand(statusHooksCmd)
 	rootCmd.AddCommand(hooksCmd)
 }
 
-const preCommitHook = `#!/bin/sh
-# aigit pre-commit hook - auto review code before commit
+// hookSpec describes a hook aigit can install.
+type hookSpec struct {
+	body    string   // shell code of the aigit block; a non-zero exit aborts the git command
+	command string   // what a hook manager should run, with "$1" and "$2" for git's arguments
+	id      string   // name of the command in hook manager configs
+	notes   []string // printed after installing
+}
+
+const preCommitBody = `# aigit review exits 1 when findings reach the configured fail_on severity
+# and 2 when the review could not run. Set AIGIT_HOOK_FAIL_CLOSED=1 to also
+# abort the commit when aigit itself fails.
 
 echo "Running aigit code review..."
 aigit review --staged --hook
+status=$?
 
-if [ $? -ne 0 ]; then
+if [ $status -eq 1 ]; then
     echo ""
     echo "Code review found issues. Commit aborted."
     echo "Use 'git commit --no-verify' to skip this check."
     exit 1
 fi
+
+if [ $status -ne 0 ]; then
+    echo ""
+    echo "aigit review failed to run (exit $status)."
+    if [ "$AIGIT_HOOK_FAIL_CLOSED" = "1" ]; then
+        echo "Commit aborted. Use 'git commit --no-verify' to skip this check."
+        exit 1
+    fi
+    echo "Continuing with commit."
+fi
 `
 
-func getHooksDir() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
-	out, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("not a git repository")
+const prepareCommitMsgBody = `# git passes the message file and the message source. aigit only drafts
+# messages for plain commits (not -m, merges, squashes or amends) and leaves
+# git's default message in place if it fails or times out.
+
+aigit commit --hook-msg-file "$1" --source "$2" </dev/null
+exit 0
+`
+
+const commitMsgBody = `# aigit lint-msg exits 1 when the message breaks a rule and 2 when it could
+# not run. From a terminal it offers an AI-rewritten message. Set
+# AIGIT_HOOK_FAIL_CLOSED=1 to also abort the commit when aigit itself fails.
+
+if { true </dev/tty; } 2>/dev/null; then
+    aigit lint-msg --quiet --fix "$1" </dev/tty
+else
+    aigit lint-msg --quiet "$1"
+fi
+status=$?
+
+if [ $status -eq 1 ]; then
+    echo ""
+    echo "Commit aborted. Use 'git commit --no-verify' to skip this check."
+    exit 1
+fi
+
+if [ $status -ne 0 ]; then
+    echo ""
+    echo "aigit lint-msg failed to run (exit $status)."
+    if [ "$AIGIT_HOOK_FAIL_CLOSED" = "1" ]; then
+        echo "Commit aborted. Use 'git commit --no-verify' to skip this check."
+        exit 1
+    fi
+    echo "Continuing with commit."
+fi
+`
+
+// hookNames lists the hooks aigit can install, in the order git runs them.
+var hookNames = []string{"pre-commit", "prepare-commit-msg", "commit-msg"}
+
+var hookSpecs = map[string]hookSpec{
+	"pre-commit": {
+		body:    preCommitBody,
+		command: "aigit review --staged --hook",
+		id:      "aigit-review",
+		notes: []string{
+			"Code will be reviewed automatically before each commit.",
+			"Use 'git commit --no-verify' to skip the review.",
+		},
+	},
+	"prepare-commit-msg": {
+		body:    prepareCommitMsgBody,
+		command: `aigit commit --hook-msg-file "$1" --source "$2" </dev/null || true`,
+		id:      "aigit-draft",
+		notes: []string{
+			"'git commit' will open the editor with an AI-drafted message.",
+			"Messages given with -m, merges, squashes and amends are left alone.",
+		},
+	},
+	"commit-msg": {
+		body:    commitMsgBody,
+		command: `aigit lint-msg --quiet "$1"`,
+		id:      "aigit-lint",
+		notes: []string{
+			"Commit messages will be checked against the configured conventions.",
+			"Use 'git commit --no-verify' to skip the check.",
+		},
+	},
+}
+
+// hookSpecFor returns the hook selected with --type.
+func hookSpecFor(name string) (hookSpec, error) {
+	spec, ok := hookSpecs[name]
+	if !ok {
+		return hookSpec{}, fmt.Errorf("unknown hook type: %s (use: %s)", name, strings.Join(hookNames, ", "))
 	}
-	gitDir := string(out[:len(out)-1])
-	return filepath.Join(gitDir, "hooks"), nil
+	return spec, nil
 }
 
 func runInstallHooks(cmd *cobra.Command, args []string) error {
-	hooksDir, err := getHooksDir()
+	spec, err := hookSpecFor(hookType)
 	if err != nil {
 		return err
 	}
-
-	if err := os.MkdirAll(hooksDir, 0755); err != nil {
-		return fmt.Errorf("failed to create hooks directory: %w", err)
+	dir, custom, err := git.HooksDir()
+	if err != nil {
+		return fmt.Errorf("not a git repository")
 	}
 
-	hookPath := filepath.Join(hooksDir, "pre-commit")
-
-	if _, err := os.Stat(hookPath); err == nil {
-		backupPath := hookPath + ".backup"
-		if err := os.Rename(hookPath, backupPath); err != nil {
-			return fmt.Errorf("failed to backup existing hook: %w", err)
+	if !hookForce {
+		root, _ := git.RepoRoot()
+		if managers := hooks.DetectManagers(root, dir); len(managers) > 0 {
+			for _, m := range managers {
+				fmt.Printf("%s manages the hooks of this repository. Add aigit to %s:\n\n", m.Name, m.ConfigFile(hookType))
+				fmt.Println(managerSnippet(m, hookType, spec))
+			}
+			fmt.Printf("Or run 'aigit hooks install --type %s --force' to write %s anyway.\n", hookType, filepath.Join(dir, hookType))
+			return nil
 		}
-		fmt.Printf("Existing pre-commit hook backed up to: %s\n", backupPath)
 	}
 
-	if err := os.WriteFile(hookPath, []byte(preCommitHook), 0755); err != nil {
-		return fmt.Errorf("failed to write hook: %w", err)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	prev, err := hooks.Install(dir, hookType, spec.body)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("✓ Pre-commit hook installed successfully!")
-	fmt.Println("  Code will be reviewed automatically before each commit.")
-	fmt.Println("  Use 'git commit --no-verify' to skip the review.")
+	switch prev {
+	case hooks.Installed, hooks.Chained:
+		fmt.Printf("✓ %s hook updated.\n", hookType)
+	case hooks.Legacy:
+		fmt.Printf("✓ %s hook upgraded.\n", hookType)
+	case hooks.Foreign:
+		fmt.Printf("✓ %s hook installed alongside the existing hook, which keeps running.\n", hookType)
+	default:
+		fmt.Printf("✓ %s hook installed successfully!\n", hookType)
+	}
+	if custom {
+		fmt.Printf("  Installed in %s (core.hooksPath).\n", dir)
+	}
+	for _, note := range spec.notes {
+		fmt.Println("  " + note)
+	}
 	return nil
 }
 
+// managerSnippet returns the configuration that runs the hook's command
+// under a hook manager.
+func managerSnippet(m hooks.Manager, hook string, spec hookSpec) string {
+	switch m.Name {
+	case hooks.Husky:
+		return fmt.Sprintf("  # %s\n  %s\n", m.ConfigFile(hook), spec.command)
+	case hooks.Lefthook:
+		run := strings.NewReplacer("$1", "{1}", "$2", "{2}").Replace(spec.command)
+		return fmt.Sprintf("  # %s\n  %s:\n    commands:\n      %s:\n        run: %s\n", m.Config, hook, spec.id, run)
+	default:
+		// pre-commit passes the message file, if any, as the last argument
+		// and the message source in the environment.
+		entry := spec.command
+		filenames := "        pass_filenames: false\n"
+		if strings.Contains(entry, "$1") {
+			entry = strings.ReplaceAll(entry, "$2", "$PRE_COMMIT_COMMIT_MSG_SOURCE")
+			entry = fmt.Sprintf("sh -c '%s' --", entry)
+			filenames = ""
+		}
+		return fmt.Sprintf("  # %s\n  - repo: local\n    hooks:\n      - id: %s\n        name: %s\n        entry: %s\n        language: system\n%s        stages: [%s]\n\n  Then run: pre-commit install --hook-type %s\n",
+			m.Config, spec.id, spec.id, entry, filenames, hook, hook)
+	}
+}
+
 func runUninstallHooks(cmd *cobra.Command, args []string) error {
-	hooksDir, err := getHooksDir()
-	if err != nil {
+	if _, err := hookSpecFor(hookType); err != nil {
 		return err
 	}
-
-	hookPath := filepath.Join(hooksDir, "pre-commit")
-
-	if _, err := os.Stat(hookPath); os.IsNotExist(err) {
-		fmt.Println("No pre-commit hook found.")
-		return nil
+	dir, _, err := git.HooksDir()
+	if err != nil {
+		return fmt.Errorf("not a git repository")
 	}
 
-	content, err := os.ReadFile(hookPath)
+	prev, err := hooks.Uninstall(dir, hookType)
+	if errors.Is(err, hooks.ErrNotInstalled) {
+		return fmt.Errorf("%s hook has no aigit block, nothing to remove", hookType)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to read hook: %w", err)
+		return err
 	}
+	switch prev {
+	case hooks.Missing:
+		fmt.Printf("No %s hook found.\n", hookType)
+	case hooks.Chained:
+		fmt.Printf("✓ aigit removed from the %s hook; the rest of the hook is kept.\n", hookType)
+	default:
+		fmt.Printf("✓ %s hook uninstalled successfully!\n", hookType)
+	}
+	return nil
+}
 
-	if string(content) != preCommitHook {
-		return fmt.Errorf("pre-commit hook was not installed by aigit, refusing to remove")
+func runHooksStatus(cmd *cobra.Command, args []string) error {
+	dir, custom, err := git.HooksDir()
+	if err != nil {
+		return fmt.Errorf("not a git repository")
 	}
+	root, _ := git.RepoRoot()
 
-	if err := os.Remove(hookPath); err != nil {
-		return fmt.Errorf("failed to remove hook: %w", err)
+	where := ""
+	if custom {
+		where = " (core.hooksPath)"
 	}
+	fmt.Printf("Hooks directory: %s%s\n", dir, where)
+	managers := hooks.DetectManagers(root, dir)
+	for _, m := range managers {
+		fmt.Printf("Hook manager:    %s (%s)\n", m.Name, m.Config)
+	}
+	fmt.Println()
 
-	backupPath := hookPath + ".backup"
-	if _, err := os.Stat(backupPath); err == nil {
-		if err := os.Rename(backupPath, hookPath); err != nil {
-			fmt.Printf("Warning: failed to restore backup hook: %v\n", err)
-		} else {
-			fmt.Println("Restored previous pre-commit hook from backup.")
+	for _, name := range hookNames {
+		state, executable, err := hooks.Inspect(dir, name)
+		if err != nil {
+			return err
+		}
+		line, mark := state.String(), " "
+		if state == hooks.Installed || state == hooks.Chained {
+			mark = "✓"
 		}
+		if state != hooks.Missing && state != hooks.Foreign && !executable {
+			line += ", but not executable so git skips it"
+		}
+		// The hook file of a manager is its own; aigit runs from its config.
+		command := strings.Join(strings.Fields(hookSpecs[name].command)[:2], " ")
+		for _, m := range managers {
+			if m.Runs(root, name, command) {
+				line, mark = "configured in "+m.ConfigFile(name), "✓"
+			}
+		}
+		fmt.Printf("  %s %-20s %s\n", mark, name, line)
 	}
-
-	fmt.Println("✓ Pre-commit hook uninstalled successfully!")
 	return nil
 }
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/git"
+)
+
+// loadIgnore returns the matcher for paths kept out of AI input, combining
+// the repository's .aigitignore with the ignore list from the config.
+func loadIgnore(cfg *config.Config) (*git.IgnoreMatcher, error) {
+	m, err := git.LoadIgnore(cfg.Ignore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ignore patterns: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-goll/aigit/internal/ai"
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/git"
+	"github.com/go-goll/aigit/internal/lint"
+)
+
+var (
+	lintFix   bool
+	lintQuiet bool
+)
+
+var lintMsgCmd = &cobra.Command{
+	Use:   "lint-msg <file>",
+	Short: "Check a commit message against the configured conventions",
+	Long: `Check a commit message file ("-" for stdin) against the repository's
+commit style and the lint settings:
+
+  convention   conventional, gitmoji or plain, from the style setting;
+               auto uses the convention of the recent history
+  lint.types   allowed conventional types (default: feat, fix, docs, ...)
+  lint.scopes  allowed scopes (default: any)
+  lint.max_subject, lint.body_wrap
+               subject and body line length limits (default: 72)
+  lint.ticket  regexp of a ticket reference the message must contain
+  lint.signoff require a Signed-off-by trailer
+
+Comment lines are ignored, and merge, revert and fixup messages are not
+checked. Exits 1 when the message breaks a rule and 2 when the check could
+not run. With --fix, an AI-rewritten message is offered when stdin is a
+terminal.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLintMsg,
+}
+
+func init() {
+	lintMsgCmd.Flags().BoolVar(&lintFix, "fix", false, "Offer an AI-rewritten message that follows the rules")
+	lintMsgCmd.Flags().BoolVarP(&lintQuiet, "quiet", "q", false, "Print nothing when the message passes")
+	rootCmd.AddCommand(lintMsgCmd)
+}
+
+func runLintMsg(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	path := args[0]
+
+	cfg, rules, err := loadLintRules(cmd)
+	if err != nil {
+		return &exitError{code: exitToolError, err: err}
+	}
+	message, err := readLintMessage(path)
+	if err != nil {
+		return &exitError{code: exitToolError, err: err}
+	}
+
+	violations := rules.Check(message)
+	if len(violations) == 0 {
+		if !lintQuiet {
+			fmt.Println("✓ Commit message follows the conventions")
+		}
+		return nil
+	}
+	printViolations(violations)
+
+	if lintFix && path != "-" && stdinIsTerminal() {
+		fixed, err := fixLintMessage(cfg, rules, path, message, violations)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Failed to rewrite commit message: %v\n", err)
+		} else if fixed {
+			return nil
+		}
+	}
+	return &exitError{
+		code: exitBlocked,
+		err:  fmt.Errorf("commit message breaks %d rule(s)", len(violations)),
+	}
+}
+
+// loadLintRules returns the commit config and the rules for the
+// repository. With style auto the convention of the recent history is
+// used, and conventional commits when the history is too short to tell.
+func loadLintRules(cmd *cobra.Command) (*config.Config, *lint.Rules, error) {
+	cfg, err := loadConfig(cmd, config.TaskLint)
+	if err != nil {
+		return nil, nil, err
+	}
+	convention := cfg.Style
+	if convention == "" || convention == config.StyleAuto {
+		convention = config.StyleConventional
+		commits, _ := git.RecentCommits(ai.StyleSampleSize)
+		if style := ai.ResolveStyle(cfg.Style, commits); style != nil {
+			convention = style.Convention
+		}
+	}
+	rules, err := lint.NewRules(cfg.Lint, convention)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, rules, nil
+}
+
+// readLintMessage reads the message file, or stdin for "-", and cleans it
+// up the way git commit does.
+func readLintMessage(path string) (string, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit message: %w", err)
+	}
+	return git.CleanupMessage(string(content), git.CommentChar()), nil
+}
+
+func printViolations(violations []lint.Violation) {
+	colorHigh.Fprintln(os.Stderr, "✗ Commit message does not follow the conventions:")
+	for _, v := range violations {
+		fmt.Fprintf(os.Stderr, "  • %s\n", v)
+	}
+}
+
+// fixLintMessage asks the model to rewrite message so that it follows the
+// rules, and writes the result to path once the user accepts it. It
+// reports whether the file now holds a compliant message.
+func fixLintMessage(cfg *config.Config, rules *lint.Rules, path, message string, violations []lint.Violation) (bool, error) {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("\nRewrite the message with AI? [Y/n]: ")
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "" && answer != "y" && answer != "yes" {
+		return false, nil
+	}
+
+	diff, err := git.GetStagedDiff()
+	if err != nil {
+		return false, fmt.Errorf("failed to get diff: %w", err)
+	}
+	ignore, err := loadIgnore(cfg)
+	if err != nil {
+		return false, err
+	}
+	diff, err = redactDiff(cfg, ignore.FilterDiff(diff))
+	if err != nil {
+		return false, err
+	}
+	prompts, err := loadPrompts(cfg, config.TaskCommit, diff)
+	if err != nil {
+		return false, err
+	}
+	client, err := ai.NewClient(cfg, prompts)
+	if err != nil {
+		return false, fmt.Errorf("failed to create AI client: %w", err)
+	}
+
+	var instruction strings.Builder
+	instruction.WriteString("Rewrite this commit message so it keeps its meaning but fixes these problems:\n")
+	for _, v := range violations {
+		fmt.Fprintf(&instruction, "- %s\n", v)
+	}
+
+	fmt.Println("Rewriting commit message...")
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutOr(60*time.Second))
+	defer cancel()
+	history := []ai.Message{{Role: ai.RoleAssistant, Content: message}}
+	rewritten, err := client.RefineCommitMessage(ctx, diff, cfg.Language, history, instruction.String())
+	if err != nil {
+		return false, err
+	}
+	rewritten = git.CleanupMessage(rewritten, git.CommentChar())
+
+	fmt.Println("\n--- Rewritten Commit Message ---")
+	fmt.Println(rewritten)
+	fmt.Println("--------------------------------")
+	if remaining := rules.Check(rewritten); len(remaining) > 0 {
+		printViolations(remaining)
+	}
+
+	fmt.Print("\nUse this message? [Y/n/e(dit)]: ")
+	answer, _ = reader.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+	case "e", "edit":
+		if rewritten, err = editMessage(rewritten); err != nil {
+			return false, err
+		}
+		if rewritten == "" {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+
+	if err := os.WriteFile(path, []byte(rewritten+"\n"), 0644); err != nil {
+		return false, fmt.Errorf("failed to write commit message: %w", err)
+	}
+	if remaining := rules.Check(rewritten); len(remaining) > 0 {
+		printViolations(remaining)
+		return false, nil
+	}
+	fmt.Println("✓ Commit message updated")
+	return true, nil
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-goll/aigit/internal/ai"
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Inspect and customise the AI prompts",
+	Long: `Show, edit or reset the prompt templates used for commit messages and reviews.
+
+Templates are Go text/template files named <name>.tmpl, looked up in the
+repository's .aigit/prompts/ first and then in ~/.aigit/prompts/. Available
+prompts: ` + strings.Join(ai.PromptNames, ", ") + `.
+
+Variables:
+  .Language       Output language (en, zh)
+  .Repo           Repository name
+  .Branch         Current branch
+  .Files          Files in the diff
+  .RecentCommits  Subjects of recent commits, newest first
+  .StyleGuide     The repository's commit style and examples (commit prompt)`,
+}
+
+var showPromptsCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Print the prompt templates in effect and where they come from",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runShowPrompts,
+}
+
+var editPromptCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Edit a prompt template, starting from the built-in one",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runEditPrompt,
+}
+
+var resetPromptCmd = &cobra.Command{
+	Use:   "reset <name>",
+	Short: "Delete a custom prompt template and restore the built-in one",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runResetPrompt,
+}
+
+var promptRepo bool
+
+func init() {
+	editPromptCmd.Flags().BoolVar(&promptRepo, "repo", false, "Edit the repository's template in .aigit/prompts/ instead of your own")
+	resetPromptCmd.Flags().BoolVar(&promptRepo, "repo", false, "Delete the repository's template instead of your own")
+	promptsCmd.AddCommand(showPromptsCmd)
+	promptsCmd.AddCommand(editPromptCmd)
+	promptsCmd.AddCommand(resetPromptCmd)
+	rootCmd.AddCommand(promptsCmd)
+}
+
+func runShowPrompts(cmd *cobra.Command, args []string) error {
+	names := ai.PromptNames
+	if len(args) == 1 {
+		if err := checkPromptName(args[0]); err != nil {
+			return err
+		}
+		names = args
+	}
+
+	for i, name := range names {
+		source, path, err := ai.FindPrompt(name)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			path = "built-in"
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("=== %s %s ===\n", name, colorFaint.Sprintf("(%s)", path))
+		fmt.Println(strings.TrimRight(source, "\n"))
+	}
+	return nil
+}
+
+func runEditPrompt(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	path, err := promptPath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		// Start from the template in effect, so a personal copy of a
+		// repository template keeps its changes.
+		source, _, err := ai.FindPrompt(name)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create prompts directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			return fmt.Errorf("failed to write prompt: %w", err)
+		}
+	}
+
+	if err := runEditor(path); err != nil {
+		return err
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt: %w", err)
+	}
+	if err := ai.CheckPrompt(name, string(source)); err != nil {
+		return fmt.Errorf("%w\nFix it with 'aigit prompts edit %s' or restore the built-in with 'aigit prompts reset %s'", err, name, name)
+	}
+	fmt.Printf("✓ Saved %s prompt to %s\n", name, path)
+	return nil
+}
+
+func runResetPrompt(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	path, err := promptPath(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No custom %s prompt at %s\n", name, path)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to delete prompt: %w", err)
+	}
+	fmt.Printf("✓ Deleted %s\n", path)
+
+	if _, other, _ := ai.FindPrompt(name); other != "" {
+		fmt.Printf("The %s prompt now comes from %s\n", name, other)
+	} else {
+		fmt.Printf("The built-in %s prompt is in effect\n", name)
+	}
+	return nil
+}
+
+// promptPath returns the template file that edit and reset work on: the
+// user's, or the repository's with --repo.
+func promptPath(name string) (string, error) {
+	if err := checkPromptName(name); err != nil {
+		return "", err
+	}
+	repo, user := ai.PromptPaths(name)
+	if promptRepo {
+		if repo == "" {
+			return "", fmt.Errorf("not a git repository")
+		}
+		return repo, nil
+	}
+	if user == "" {
+		return "", fmt.Errorf("failed to locate home directory")
+	}
+	return user, nil
+}
+
+func checkPromptName(name string) error {
+	if !slices.Contains(ai.PromptNames, name) {
+		return fmt.Errorf("unknown prompt: %s (use: %s)", name, strings.Join(ai.PromptNames, ", "))
+	}
+	return nil
+}
+
+// loadPrompts renders the prompts for the diff about to be sent, to be
+// passed to ai.NewClient. For commits it also learns the repository's commit
+// style and, when the language was left at its default, adopts the language
+// of its history.
+func loadPrompts(cfg *config.Config, task config.Task, diff string) (ai.Prompts, error) {
+	return loadPromptsFrom(cfg, task, diff, "HEAD")
+}
+
+// loadPromptsFrom is loadPrompts with the recent commits and style sample
+// taken from the history of rev instead of HEAD, for commits that are being
+// rewritten and must not serve as examples of themselves.
+func loadPromptsFrom(cfg *config.Config, task config.Task, diff, rev string) (ai.Prompts, error) {
+	data := ai.PromptData{
+		Branch: git.CurrentBranch(),
+	}
+	if root, err := git.RepoRoot(); err == nil {
+		data.Repo = filepath.Base(root)
+	}
+	for _, f := range git.ParseDiff(diff) {
+		if !slices.Contains(data.Files, f.Path()) {
+			data.Files = append(data.Files, f.Path())
+		}
+	}
+
+	n := 10
+	if task == config.TaskCommit {
+		n = ai.StyleSampleSize
+	}
+	commits, _ := git.RecentCommitsFrom(rev, n)
+	for i, c := range commits {
+		if i == 10 {
+			break
+		}
+		data.RecentCommits = append(data.RecentCommits, c.Subject)
+	}
+
+	if task == config.TaskCommit {
+		if style := ai.ResolveStyle(cfg.Style, commits); style != nil {
+			auto := cfg.Style == "" || cfg.Style == config.StyleAuto
+			if auto && cfg.Origin("language") == config.OriginDefault {
+				cfg.Language = style.Language
+			}
+			data.StyleGuide = style.Guide(cfg.Language, data.Branch)
+		}
+	}
+	data.Language = cfg.Language
+
+	// Commit messages and branch names can hold secrets just like the diff.
+	fields := []*string{&data.Repo, &data.Branch, &data.StyleGuide}
+	for i := range data.Files {
+		fields = append(fields, &data.Files[i])
+	}
+	for i := range data.RecentCommits {
+		fields = append(fields, &data.RecentCommits[i])
+	}
+	if err := redactFields(cfg, "commit history", fields...); err != nil {
+		return ai.Prompts{}, err
+	}
+	return ai.LoadPrompts(data)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/redact"
+)
+
+// redactDiff masks credentials in text before it is sent to a provider, as
+// configured by cfg.Redact. Masked secrets are reported on stderr so that
+// machine-readable output on stdout is not affected.
+func redactDiff(cfg *config.Config, text string) (string, error) {
+	return redactText(cfg, text, "diff")
+}
+
+// redactText is redactDiff for text other than a diff, named by what in the
+// messages.
+func redactText(cfg *config.Config, text, what string) (string, error) {
+	mode, err := redact.ParseMode(cfg.Redact)
+	if err != nil {
+		return "", err
+	}
+	if mode == redact.ModeOff {
+		return text, nil
+	}
+
+	r, err := redact.New(cfg.RedactPatterns)
+	if err != nil {
+		return "", err
+	}
+
+	masked, matches := r.Redact(text)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	if mode == redact.ModeBlock {
+		fmt.Fprintf(os.Stderr, "Possible secrets found in the %s:\n", what)
+		printRedactions(matches)
+		return "", fmt.Errorf("refusing to send %s containing %d possible secret(s) (redact: block)", what, len(matches))
+	}
+
+	colorMedium.Fprintf(os.Stderr, "⚠ Masked %d possible secret(s) before sending:\n", len(matches))
+	printRedactions(matches)
+	return masked, nil
+}
+
+func printRedactions(matches []redact.Match) {
+	for _, m := range matches {
+		fmt.Fprintf(os.Stderr, "  • %s in %s\n", m.Kind, m.Location())
+	}
+}
+
+// redactFields runs several texts through redactText at once, replacing each
+// with its masked form, so that secrets across them are reported together.
+func redactFields(cfg *config.Config, what string, fields ...*string) error {
+	texts := make([]string, len(fields))
+	for i, f := range fields {
+		texts[i] = *f
+	}
+	masked, err := redactText(cfg, strings.Join(texts, "\n"), what)
+	if err != nil {
+		return err
+	}
+
+	// Redaction keeps the number of lines, so each field is the same
+	// number of lines it started with.
+	lines := strings.Split(masked, "\n")
+	for i, f := range fields {
+		n := strings.Count(texts[i], "\n") + 1
+		*f = strings.Join(lines[:n], "\n")
+		lines = lines[n:]
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/go-goll/aigit/internal/ai"
 	"github.com/go-goll/aigit/internal/config"
 	"github.com/go-goll/aigit/internal/git"
+	"github.com/go-goll/aigit/internal/report"
 )
 
 var (
@@ -24,116 +27,312 @@ var (
 var (
 	reviewStaged bool
 	hookMode     bool
+	failOn       string
+	reviewFormat string
+	reviewOutput string
+	reviewBase   string
 )
 
 var reviewCmd = &cobra.Command{
-	Use:   "review",
+	Use:   "review [<rev> | <A..B> | <A...B>]",
 	Short: "Review code changes for potential bugs",
-	Long:  `Analyze code changes using AI to identify potential bugs, security issues, and code quality problems.`,
-	RunE:  runReview,
+	Long: `Analyze code changes using AI to identify potential bugs, security issues, and code quality problems.
+
+Without arguments the working tree changes are reviewed. Pass a commit to
+review what it introduced, a range to review several commits, or --base to
+review the current branch against its merge base with another branch:
+
+  aigit review HEAD~1
+  aigit review main..feature
+  aigit review --base main
+
+In hook mode (--hook) the command exits with code 1 when findings at or above
+the fail_on severity are present, and with code 2 when the review itself
+could not be performed (git, config or provider errors).`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runReview,
 }
 
 func init() {
 	reviewCmd.Flags().BoolVarP(&reviewStaged, "staged", "s", false, "Review only staged changes (default: all changes)")
 	reviewCmd.Flags().BoolVar(&hookMode, "hook", false, "Run in hook mode (exit with error if issues found)")
+	reviewCmd.Flags().StringVar(&failOn, "fail-on", "", "Minimum severity that fails hook mode: high, medium, low (default from config)")
+	reviewCmd.Flags().StringVarP(&reviewFormat, "format", "f", "text", "Output format: text, json, sarif")
+	reviewCmd.Flags().StringVarP(&reviewOutput, "output", "o", "", "Write the review to a file instead of stdout")
+	reviewCmd.Flags().StringVar(&reviewBase, "base", "", "Review HEAD against its merge base with this branch")
+	reviewCmd.Flags().IntVar(&maxTokensIn, "max-tokens-in", 0, "Input token budget; larger diffs are reviewed in parts (default: from model context window)")
 }
 
 func runReview(cmd *cobra.Command, args []string) error {
-	if !git.IsGitRepo() {
-		return fmt.Errorf("not a git repository")
+	if hookMode {
+		cmd.SilenceUsage = true
 	}
 
-	cfg, err := config.Load()
+	result, threshold, err := performReview(cmd, args)
 	if err != nil {
+		if hookMode {
+			return &exitError{code: exitToolError, err: err}
+		}
 		return err
 	}
 
-	var diff string
+	if err := writeReview(result); err != nil {
+		if hookMode {
+			return &exitError{code: exitToolError, err: err}
+		}
+		return err
+	}
+
+	if !hookMode {
+		return nil
+	}
+
+	if n := result.CountAtOrAbove(threshold); n > 0 {
+		return &exitError{
+			code: exitBlocked,
+			err:  fmt.Errorf("review found %d issue(s) at or above %s severity", n, threshold),
+		}
+	}
+	return nil
+}
+
+func performReview(cmd *cobra.Command, args []string) (*ai.ReviewResult, ai.Severity, error) {
+	if !git.IsGitRepo() {
+		return nil, "", fmt.Errorf("not a git repository")
+	}
+
+	cfg, err := loadConfig(cmd, config.TaskReview)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch reviewFormat {
+	case "text", "json", "sarif":
+	default:
+		return nil, "", fmt.Errorf("invalid format: %s (use: text, json, sarif)", reviewFormat)
+	}
+
+	threshold, err := ai.ParseSeverity(cfg.FailOn)
+	if err != nil {
+		return nil, "", err
+	}
+
+	diff, input, err := collectReviewDiff(args)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ignore, err := loadIgnore(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	input, err = redactDiff(cfg, ignore.FilterDiff(input))
+	if err != nil {
+		return nil, "", err
+	}
+
+	prompts, err := loadPrompts(cfg, config.TaskReview, input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := ai.NewClient(cfg, prompts)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create AI client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutOr(120*time.Second))
+	defer cancel()
+
+	var result *ai.ReviewResult
+	if streamer, ok := client.(ai.Streamer); ok && reviewFormat == "text" && reviewOutput == "" && stdoutIsTerminal() {
+		result, err = streamer.StreamReviewCode(ctx, input, cfg.Language, printFaintToken)
+		fmt.Println()
+	} else {
+		result, err = client.ReviewCode(ctx, input, cfg.Language)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to review code: %w", err)
+	}
+
+	report.AnchorFindings(result.Findings, git.ParseDiff(diff))
+
+	return result, threshold, nil
+}
+
+// collectReviewDiff returns the diff selected by the flags and arguments,
+// along with the text sent to the reviewer, which for commit ranges also
+// carries the commit messages.
+func collectReviewDiff(args []string) (diff, input string, err error) {
+	if len(args) > 0 || reviewBase != "" {
+		if reviewStaged {
+			return "", "", fmt.Errorf("--staged cannot be combined with a revision or --base")
+		}
+		if len(args) > 0 && reviewBase != "" {
+			return "", "", fmt.Errorf("--base cannot be combined with a revision argument")
+		}
+		return collectRangeDiff(args)
+	}
+
 	if reviewStaged {
 		diff, err = git.GetStagedDiff()
 		if err != nil {
-			return fmt.Errorf("failed to get staged diff: %w", err)
+			return "", "", fmt.Errorf("failed to get staged diff: %w", err)
 		}
 		if diff == "" {
-			return fmt.Errorf("no staged changes to review")
+			return "", "", fmt.Errorf("no staged changes to review")
 		}
-		fmt.Println("Reviewing staged changes...")
+		fmt.Fprintln(statusOut(), "Reviewing staged changes...")
 	} else {
 		diff, err = git.GetAllDiff()
 		if err != nil {
-			return fmt.Errorf("failed to get diff: %w", err)
+			return "", "", fmt.Errorf("failed to get diff: %w", err)
 		}
-		fmt.Println("Reviewing all changes...")
+		fmt.Fprintln(statusOut(), "Reviewing all changes...")
 	}
+	return diff, diff, nil
+}
 
-	client, err := ai.NewClient(cfg)
+func collectRangeDiff(args []string) (diff, input string, err error) {
+	var rng *git.RevRange
+	if reviewBase != "" {
+		rng, err = git.ResolveBranchRange(reviewBase)
+	} else {
+		rng, err = git.ResolveRange(args[0])
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create AI client: %w", err)
+		return "", "", err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-	defer cancel()
+	diff, err = git.GetRangeDiff(rng)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get diff: %w", err)
+	}
+	if diff == "" {
+		return "", "", fmt.Errorf("no changes in the selected range")
+	}
 
-	result, err := client.ReviewCode(ctx, diff, cfg.Language)
+	commits, err := git.GetRangeCommits(rng)
 	if err != nil {
-		return fmt.Errorf("failed to review code: %w", err)
+		return "", "", fmt.Errorf("failed to list commits: %w", err)
 	}
 
-	fmt.Println("\n=== Code Review Results ===")
-	printColoredResult(result)
-	fmt.Println("===========================")
+	out := statusOut()
+	fmt.Fprintf(out, "Reviewing %d commit(s):\n", len(commits))
+	messages := make([]string, 0, len(commits))
+	for _, c := range commits {
+		fmt.Fprintf(out, "  • %s %s\n", c.ShortHash(), c.Subject)
+		messages = append(messages, strings.TrimSpace(c.Subject+"\n\n"+c.Body))
+	}
 
-	return nil
+	return diff, ai.WithCommitContext(diff, messages), nil
 }
 
-func printColoredResult(result string) {
-	lines := strings.Split(result, "\n")
-	for _, line := range lines {
-		coloredLine := colorLine(line)
-		fmt.Println(coloredLine)
+// statusOut is where progress messages go: stderr when a machine-readable
+// report is written to stdout, so the report stays parseable.
+func statusOut() io.Writer {
+	if reviewFormat != "text" && reviewOutput == "" {
+		return os.Stderr
 	}
+	return os.Stdout
 }
 
-func colorLine(line string) string {
-	upperLine := strings.ToUpper(line)
+func writeReview(result *ai.ReviewResult) error {
+	var w io.Writer = os.Stdout
+	if reviewOutput != "" {
+		f, err := os.Create(reviewOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
 
-	if containsAny(upperLine, []string{"HIGH", "高严重性", "CRITICAL"}) {
-		return colorHigh.Sprint(line)
+	var err error
+	switch reviewFormat {
+	case "json":
+		err = report.WriteJSON(w, result)
+	case "sarif":
+		err = report.WriteSARIF(w, result)
+	default:
+		if reviewOutput != "" {
+			color.NoColor = true
+		}
+		fmt.Fprintln(w, "\n=== Code Review Results ===")
+		printFindings(w, result)
+		fmt.Fprintln(w, "===========================")
 	}
-	if containsAny(upperLine, []string{"MEDIUM", "中严重性", "中", "WARN"}) {
-		return colorMedium.Sprint(line)
+	if err != nil {
+		return fmt.Errorf("failed to write review: %w", err)
 	}
-	if containsAny(upperLine, []string{"LOW", "低", "非严重问题", "INFO"}) {
-		return colorLow.Sprint(line)
+
+	if reviewOutput != "" {
+		fmt.Printf("✓ Review written to %s (%d finding(s))\n", reviewOutput, len(result.Findings))
 	}
-	if containsAny(upperLine, []string{"NO CRITICAL", "NO ISSUE", "未发现", "NO SIGNIFICANT", "LOOKS GOOD", "NO PROBLEM"}) {
-		return colorOK.Sprint(line)
+	return nil
+}
+
+func printFindings(w io.Writer, result *ai.ReviewResult) {
+	if result.Summary != "" {
+		fmt.Fprintln(w, result.Summary)
+		fmt.Fprintln(w)
 	}
 
-	return line
-}
+	if len(result.Findings) == 0 {
+		colorOK.Fprintln(w, "No significant issues found.")
+		return
+	}
 
-func containsAny(s string, substrs []string) bool {
-	for _, sub := range substrs {
-		if strings.Contains(s, sub) {
-			return true
+	for i, f := range result.Findings {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		severityColor(f.Severity).Fprintf(w, "[%s] %s\n", strings.ToUpper(string(f.Severity)), f.Title)
+
+		var meta []string
+		if loc := findingLocation(f); loc != "" {
+			meta = append(meta, loc)
+		}
+		if f.Category != "" {
+			meta = append(meta, f.Category)
+		}
+		if len(meta) > 0 {
+			fmt.Fprintf(w, "  %s\n", strings.Join(meta, " · "))
+		}
+		if f.Explanation != "" {
+			fmt.Fprintf(w, "  %s\n", indent(f.Explanation, "  "))
+		}
+		if f.SuggestedFix != "" {
+			fmt.Fprintf(w, "  Fix: %s\n", indent(f.SuggestedFix, "  "))
 		}
 	}
-	return false
 }
 
-func hasHighSeverityIssues(result string) bool {
-	upperResult := strings.ToUpper(result)
-	keywords := []string{
-		"HIGH", "高",
-		"CRITICAL", "严重",
-		"SECURITY", "安全漏洞",
-		"VULNERABILITY", "漏洞",
+func severityColor(s ai.Severity) *color.Color {
+	switch s {
+	case ai.SeverityHigh:
+		return colorHigh
+	case ai.SeverityMedium:
+		return colorMedium
+	default:
+		return colorLow
 	}
-	for _, kw := range keywords {
-		if strings.Contains(upperResult, strings.ToUpper(kw)) {
-			return true
-		}
+}
+
+func findingLocation(f ai.Finding) string {
+	switch {
+	case f.File == "":
+		return ""
+	case f.StartLine <= 0:
+		return f.File
+	case f.EndLine > f.StartLine:
+		return fmt.Sprintf("%s:%d-%d", f.File, f.StartLine, f.EndLine)
+	default:
+		return fmt.Sprintf("%s:%d", f.File, f.StartLine)
 	}
-	return false
+}
+
+func indent(s, prefix string) string {
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n"+prefix)
 }
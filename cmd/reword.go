@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/go-goll/aigit/internal/ai"
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/git"
+)
+
+var (
+	rewordYes   bool
+	rewordForce bool
+)
+
+var rewordCmd = &cobra.Command{
+	Use:   "reword [<rev> | <A..B> | <A...B>]",
+	Short: "Regenerate the messages of existing commits",
+	Long: `Generate new messages for existing commits from their own diffs and rewrite
+them. Without arguments HEAD is reworded; pass a commit or a range to reword
+several:
+
+  aigit reword HEAD~3..
+  aigit reword main..
+
+The old and new subjects are shown before anything is rewritten. HEAD alone is
+amended; older commits are rewritten with a rebase, so every later commit gets
+a new id. Commits already on the branch's upstream are refused unless --force
+is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runReword,
+}
+
+func init() {
+	rewordCmd.Flags().BoolVarP(&rewordYes, "yes", "y", false, "Rewrite without confirmation")
+	rewordCmd.Flags().BoolVar(&rewordForce, "force", false, "Reword commits that are already on the upstream branch")
+	rootCmd.AddCommand(rewordCmd)
+}
+
+func runReword(cmd *cobra.Command, args []string) error {
+	if !git.IsGitRepo() {
+		return fmt.Errorf("not a git repository")
+	}
+
+	commits, base, err := rewordTargets(args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd, config.TaskCommit)
+	if err != nil {
+		return err
+	}
+	ignore, err := loadIgnore(cfg)
+	if err != nil {
+		return err
+	}
+
+	diffs := make([]string, len(commits))
+	for i, c := range commits {
+		if diffs[i], err = rewordDiff(cfg, ignore, c); err != nil {
+			return fmt.Errorf("failed to get diff of %s: %w", c.ShortHash(), err)
+		}
+	}
+	// The style is learnt from the history before the range; the messages
+	// being replaced are no examples to follow.
+	prompts, err := loadPromptsFrom(cfg, config.TaskCommit, strings.Join(diffs, "\n"), base)
+	if err != nil {
+		return err
+	}
+	client, err := ai.NewClient(cfg, prompts)
+	if err != nil {
+		return fmt.Errorf("failed to create AI client: %w", err)
+	}
+
+	messages := make([]string, len(commits))
+	for i, c := range commits {
+		fmt.Printf("Generating message for %s (%d/%d)...\n", c.ShortHash(), i+1, len(commits))
+		message, err := rewordMessage(cfg, client, c, diffs[i])
+		if err != nil {
+			return fmt.Errorf("failed to generate message for %s: %w", c.ShortHash(), err)
+		}
+		messages[i] = message
+	}
+
+	printRewordTable(commits, messages)
+
+	changed := make(map[string]string)
+	for i, c := range commits {
+		if messages[i] != strings.TrimSpace(c.Subject+"\n\n"+c.Body) {
+			changed[c.Hash] = messages[i]
+		}
+	}
+	if len(changed) == 0 {
+		fmt.Println("\nNothing to reword.")
+		return nil
+	}
+
+	if !rewordYes {
+		fmt.Printf("\nReword %d commit(s)? [Y/n]: ", len(changed))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "" && answer != "y" && answer != "yes" {
+			fmt.Println("Reword aborted.")
+			return nil
+		}
+	}
+
+	if len(commits) == 1 && commits[0].Hash == git.Head() {
+		err = git.AmendMessage(messages[0])
+	} else {
+		err = git.RewordCommits(base, changed)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rewrite commits: %w", err)
+	}
+	fmt.Printf("✓ Reworded %d commit(s)\n", len(changed))
+	return nil
+}
+
+// rewordTargets resolves the commits selected by args, oldest first, and the
+// commit the rewrite starts from ("" for the root). It refuses commits that
+// are not on the current branch, histories with merges and, without --force,
+// commits the upstream already has.
+func rewordTargets(args []string) ([]git.CommitInfo, string, error) {
+	spec := "HEAD"
+	if len(args) > 0 {
+		spec = args[0]
+	}
+	rng, err := git.ResolveRange(spec)
+	if err != nil {
+		return nil, "", err
+	}
+	if !git.IsAncestor(rng.Head, "HEAD") {
+		return nil, "", fmt.Errorf("%s is not on the current branch", spec)
+	}
+
+	commits, err := git.GetRangeCommits(rng)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list commits: %w", err)
+	}
+	if len(commits) == 0 {
+		return nil, "", fmt.Errorf("no commits in the selected range")
+	}
+
+	base := git.Parent(commits[0].Hash)
+	merges, err := git.HasMerges(base)
+	if err != nil {
+		return nil, "", err
+	}
+	if merges {
+		return nil, "", fmt.Errorf("cannot reword across merge commits")
+	}
+
+	if upstream := git.Upstream(); upstream != "" && !rewordForce {
+		published := 0
+		for _, c := range commits {
+			if git.IsAncestor(c.Hash, upstream) {
+				published++
+			}
+		}
+		if published > 0 {
+			return nil, "", fmt.Errorf("%d of the selected commits are already on %s; rewriting them makes the branch diverge (use --force to reword anyway)", published, upstream)
+		}
+	}
+	return commits, base, nil
+}
+
+// rewordDiff returns the changes c introduced, filtered and redacted as they
+// will be sent.
+func rewordDiff(cfg *config.Config, ignore *git.IgnoreMatcher, c git.CommitInfo) (string, error) {
+	rng, err := git.ResolveRange(c.Hash)
+	if err != nil {
+		return "", err
+	}
+	diff, err := git.GetRangeDiff(rng)
+	if err != nil || diff == "" {
+		return "", err
+	}
+	return redactDiff(cfg, ignore.FilterDiff(diff))
+}
+
+// rewordMessage generates a message for c from diff, the changes it
+// introduced. Commits without changes keep their message.
+func rewordMessage(cfg *config.Config, client ai.Client, c git.CommitInfo, diff string) (string, error) {
+	if diff == "" {
+		return strings.TrimSpace(c.Subject + "\n\n" + c.Body), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutOr(60*time.Second))
+	defer cancel()
+	message, err := client.GenerateCommitMessage(ctx, diff, cfg.Language)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(message), nil
+}
+
+// printRewordTable shows the old and new subject of each commit.
+func printRewordTable(commits []git.CommitInfo, messages []string) {
+	const maxBefore = 40
+	width := len("Before")
+	for _, c := range commits {
+		width = max(width, min(utf8.RuneCountInString(c.Subject), maxBefore))
+	}
+
+	fmt.Println()
+	colorFaint.Printf("%-7s  %-*s  %s\n", "Commit", width, "Before", "After")
+	for i, c := range commits {
+		before := c.Subject
+		if utf8.RuneCountInString(before) > maxBefore {
+			before = string([]rune(before)[:maxBefore-1]) + "…"
+		}
+		after, _, _ := strings.Cut(messages[i], "\n")
+		pad := width - utf8.RuneCountInString(before)
+		fmt.Printf("%-7s  %s%s  %s\n", c.ShortHash(), before, strings.Repeat(" ", pad), after)
+	}
+}
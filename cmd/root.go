@@ -1,29 +1,150 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/go-goll/aigit/internal/ai"
+	"github.com/go-goll/aigit/internal/config"
 	"github.com/spf13/cobra"
 )
 
+// Exit codes returned by commands that run from git hooks, so hook scripts
+// can tell "blocked by findings" apart from "aigit itself failed".
+const (
+	exitBlocked   = 1
+	exitToolError = 2
+)
+
+// exitError carries a specific process exit code up to Execute.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
 var rootCmd = &cobra.Command{
 	Use:   "aigit",
 	Short: "AI-powered git commit message generator",
 	Long: `aigit is a CLI tool that uses AI to generate meaningful git commit messages
 and review code changes for potential bugs.
 
-Supported AI providers: OpenAI, Claude, Google Gemini`,
+Supported AI providers: OpenAI, Claude, Google Gemini, OpenRouter, Ollama`,
+	PersistentPreRunE: setupDebug,
+	// Execute prints the error itself.
+	SilenceErrors: true,
+}
+
+var (
+	debugMode bool
+	debugFile string
+)
+
+// setupDebug turns on request logging for --debug, --debug-file or
+// AIGIT_DEBUG=1. Logs go to stderr unless a file is given.
+func setupDebug(cmd *cobra.Command, args []string) error {
+	if debugFile != "" {
+		f, err := os.OpenFile(debugFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open debug file: %w", err)
+		}
+		ai.EnableDebug(f)
+		return nil
+	}
+	if debugMode || os.Getenv("AIGIT_DEBUG") == "1" {
+		ai.EnableDebug(os.Stderr)
+	}
+	return nil
 }
 
 func Execute() {
+	fromHook := hookInvocation(os.Args[1:])
+	if fromHook {
+		reviewCmd.SilenceUsage = true
+		lintMsgCmd.SilenceUsage = true
+	}
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		code := 1
+		var ee *exitError
+		switch {
+		case errors.As(err, &ee):
+			code = ee.code
+		case fromHook:
+			// Hook scripts read 1 as "blocked by findings"; a typo or a
+			// failure of aigit itself must not look like one.
+			code = exitToolError
+		}
+		os.Exit(code)
+	}
+}
+
+// hookInvocation reports whether args run one of the commands hook scripts
+// call: review in hook mode or lint-msg. The raw arguments are inspected
+// because a flag error stops parsing before --hook may be seen.
+func hookInvocation(args []string) bool {
+	cmd, rest, err := rootCmd.Find(args)
+	if err != nil {
+		return false
+	}
+	switch cmd {
+	case lintMsgCmd:
+		return true
+	case reviewCmd:
+		for _, arg := range rest {
+			if arg == "--hook" || arg == "--hook=true" || arg == "--hook=1" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// configFlags maps command-line flags to the config keys they override.
+// Flags are only applied when the running command defines them.
+var configFlags = []struct {
+	flag, key string
+}{
+	{"provider", "provider"},
+	{"model", "model"},
+	{"language", "language"},
+	{"fail-on", "fail_on"},
+	{"max-tokens-in", "max_tokens_in"},
+}
+
+// loadConfig returns the effective configuration for task, using the
+// --profile flag if given, with the flags the user passed to cmd applied on
+// top.
+func loadConfig(cmd *cobra.Command, task config.Task) (*config.Config, error) {
+	profile, _ := cmd.Flags().GetString("profile")
+	cfg, err := config.LoadFor(task, profile)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range configFlags {
+		flag := cmd.Flags().Lookup(f.flag)
+		if flag == nil || !flag.Changed {
+			continue
+		}
+		if err := cfg.Override(f.key, flag.Value.String(), "flag --"+f.flag); err != nil {
+			return nil, err
+		}
 	}
+	return cfg, nil
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&debugMode, "debug", false, "Log provider requests and responses (credentials masked) to stderr")
+	rootCmd.PersistentFlags().StringVar(&debugFile, "debug-file", "", "Write the --debug log to this file instead of stderr")
+	rootCmd.PersistentFlags().String("profile", "", "Use a named profile from the config (default: commit_profile or review_profile)")
+	rootCmd.PersistentFlags().String("provider", "", "Override the configured AI provider")
+	rootCmd.PersistentFlags().String("model", "", "Override the configured model")
+	rootCmd.PersistentFlags().String("language", "", "Override the output language (en, zh)")
+
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(commitCmd)
 	rootCmd.AddCommand(reviewCmd)
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/go-goll/aigit/internal/ai"
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/git"
+)
+
+// splitUnit is one numbered hunk offered to the model: a hunk of a file, or
+// a whole file whose change has no hunks, such as a binary file, a mode
+// change or an empty new file.
+type splitUnit struct {
+	file int
+	hunk int // -1 for a change without hunks
+}
+
+// commitSplit asks the model to group the staged hunks into commits, shows
+// the plan and creates the commits one by one.
+func commitSplit(cfg *config.Config, client ai.Client, ignore *git.IgnoreMatcher, diff string) error {
+	patch, err := git.GetStagedPatch()
+	if err != nil {
+		return fmt.Errorf("failed to get diff: %w", err)
+	}
+	files := git.ParseDiff(patch)
+	units := splitUnits(files)
+	if len(units) < 2 {
+		fmt.Println("Only one change is staged; nothing to split.")
+		return commitSingle(cfg, client, diff)
+	}
+
+	listing, err := redactDiff(cfg, describeUnits(files, units, ignore))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Planning commits for %d hunks...\n", len(units))
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.TimeoutOr(60*time.Second))
+	plan, err := ai.PlanSplit(ctx, client, listing, cfg.Language, len(units))
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to plan commits: %w", err)
+	}
+
+	printPlan(plan, files, units)
+	if !autoCommit {
+		fmt.Printf("\nCreate these %d commits? [Y/n]: ", len(plan.Commits))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "" && answer != "y" && answer != "yes" {
+			fmt.Println("Commit aborted.")
+			return nil
+		}
+	}
+	return applyPlan(plan, files, units)
+}
+
+func splitUnits(files []git.FileDiff) []splitUnit {
+	var units []splitUnit
+	for i, f := range files {
+		if len(f.Hunks) == 0 {
+			units = append(units, splitUnit{file: i, hunk: -1})
+			continue
+		}
+		for j := range f.Hunks {
+			units = append(units, splitUnit{file: i, hunk: j})
+		}
+	}
+	return units
+}
+
+// describeUnits lists the numbered hunks for the model. Excluded files are
+// described by their size only.
+func describeUnits(files []git.FileDiff, units []splitUnit, ignore *git.IgnoreMatcher) string {
+	var b strings.Builder
+	for i, u := range units {
+		f := files[u.file]
+		fmt.Fprintf(&b, "### Hunk %d: %s", i+1, f.Path())
+		if note := fileNote(f); note != "" {
+			fmt.Fprintf(&b, " (%s)", note)
+		}
+		b.WriteString("\n")
+		switch {
+		case u.hunk < 0:
+		case ignore.Match(f.Path()):
+			h := f.Hunks[u.hunk]
+			fmt.Fprintf(&b, "%s\n# %d lines changed (excluded from AI input)\n", h.Header, len(h.Lines))
+		default:
+			b.WriteString(f.Hunks[u.hunk].String())
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// fileNote describes what a file's header changes besides its content.
+func fileNote(f git.FileDiff) string {
+	var notes []string
+	for _, line := range f.Header {
+		switch {
+		case strings.HasPrefix(line, "new file mode"):
+			notes = append(notes, "new file")
+		case strings.HasPrefix(line, "deleted file mode"):
+			notes = append(notes, "deleted")
+		case strings.HasPrefix(line, "rename from "):
+			notes = append(notes, "renamed from "+strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "new mode "):
+			notes = append(notes, "mode "+strings.TrimPrefix(line, "new mode "))
+		}
+	}
+	if f.IsBinary {
+		notes = append(notes, "binary")
+	}
+	return strings.Join(notes, ", ")
+}
+
+func printPlan(plan *ai.SplitPlan, files []git.FileDiff, units []splitUnit) {
+	for i, c := range plan.Commits {
+		fmt.Printf("\n--- Commit %d/%d ---\n", i+1, len(plan.Commits))
+		fmt.Println(c.Message)
+		fmt.Println()
+		for _, n := range c.Hunks {
+			u := units[n-1]
+			f := files[u.file]
+			switch note := fileNote(f); {
+			case u.hunk >= 0:
+				colorFaint.Printf("  • %s %s\n", f.Path(), f.Hunks[u.hunk].Header)
+			case note != "":
+				fmt.Printf("  • %s (%s)\n", f.Path(), note)
+			default:
+				fmt.Printf("  • %s\n", f.Path())
+			}
+		}
+	}
+	fmt.Println("--------------------------------")
+}
+
+// applyPlan creates the planned commits, staging each one's hunks in a
+// temporary index built from the previous commit. The real index is not
+// touched: once every hunk is committed it matches HEAD again. If a commit
+// fails or the user interrupts, HEAD and the index are put back as they
+// were.
+func applyPlan(plan *ai.SplitPlan, files []git.FileDiff, units []splitUnit) error {
+	restore, err := git.SaveIndex()
+	if err != nil {
+		return err
+	}
+	head := git.Head()
+	index, err := git.NewTempIndex()
+	if err != nil {
+		return err
+	}
+	defer index.Remove()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	rollback := func() {
+		if err := git.ResetHead(head); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Failed to restore HEAD to %s: %v\n", head, err)
+		}
+		if err := restore(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Failed to restore the index: %v\n", err)
+		}
+	}
+
+	applied := make(map[int]bool) // files whose header has been applied
+	for i, c := range plan.Commits {
+		err := ctx.Err()
+		if err == nil {
+			err = index.Reset()
+		}
+		if err == nil {
+			err = index.Apply(planPatch(files, units, c.Hunks, applied))
+		}
+		if err == nil {
+			err = index.Commit(c.Message)
+		}
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to create commit %d/%d, original HEAD and index restored: %w", i+1, len(plan.Commits), err)
+		}
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		fmt.Printf("✓ Committed %d/%d: %s\n", i+1, len(plan.Commits), subject)
+	}
+
+	if remaining, err := git.GetStagedDiff(); err == nil && remaining != "" {
+		colorMedium.Fprintln(os.Stderr, "⚠ Some staged changes were not committed and remain staged.")
+	}
+	return nil
+}
+
+// planPatch builds the patch for one planned commit. A file's own header
+// goes with its first hunk to be applied; later hunks of the file use a
+// plain header, since any rename or creation has already happened.
+func planPatch(files []git.FileDiff, units []splitUnit, hunks []int, applied map[int]bool) string {
+	byFile := make(map[int][]int)
+	var order []int
+	for _, n := range hunks {
+		u := units[n-1]
+		if _, ok := byFile[u.file]; !ok {
+			order = append(order, u.file)
+			byFile[u.file] = nil
+		}
+		if u.hunk >= 0 {
+			byFile[u.file] = append(byFile[u.file], u.hunk)
+		}
+	}
+
+	var b strings.Builder
+	for _, i := range order {
+		b.WriteString(files[i].Patch(byFile[i], !applied[i]))
+		applied[i] = true
+	}
+	return b.String()
+}
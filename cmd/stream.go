@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+var colorFaint = color.New(color.Faint)
+
+// stdoutIsTerminal reports whether live token output can be shown. When
+// stdout is piped or redirected the full response is printed at once.
+func stdoutIsTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// stdinIsTerminal reports whether the user can answer prompts.
+func stdinIsTerminal() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+func printToken(token string) {
+	fmt.Print(token)
+}
+
+func printFaintToken(token string) {
+	colorFaint.Print(token)
+}
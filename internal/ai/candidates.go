@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// MaxCandidates bounds how many commit messages can be requested at once.
+const MaxCandidates = 5
+
+// CandidateGenerator is implemented by clients that can produce several
+// alternative commit messages for the same diff.
+type CandidateGenerator interface {
+	GenerateCommitMessages(ctx context.Context, diff, language string, n int) ([]string, error)
+}
+
+var (
+	_ CandidateGenerator = (*OpenAIClient)(nil)
+	_ CandidateGenerator = (*GoogleClient)(nil)
+	_ CandidateGenerator = (*chunkingClient)(nil)
+	_ CandidateGenerator = (*retryClient)(nil)
+	_ CandidateGenerator = (*fallbackClient)(nil)
+)
+
+// GenerateCommitMessages returns up to n distinct commit messages for diff.
+// Providers that support it answer in a single request; otherwise n requests
+// run in parallel. Duplicates are dropped, so fewer than n may be returned.
+func GenerateCommitMessages(ctx context.Context, client Client, diff, language string, n int) ([]string, error) {
+	var messages []string
+	var err error
+	if g, ok := client.(CandidateGenerator); ok && n > 1 {
+		messages, err = g.GenerateCommitMessages(ctx, diff, language, n)
+	} else {
+		messages, err = parallelCandidates(ctx, n, func(ctx context.Context) (string, error) {
+			return client.GenerateCommitMessage(ctx, diff, language)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return uniqueMessages(messages), nil
+}
+
+// parallelCandidates runs fn n times concurrently. Failed runs are dropped
+// as long as at least one succeeds.
+func parallelCandidates(ctx context.Context, n int, fn func(context.Context) (string, error)) ([]string, error) {
+	results := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = fn(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	var messages []string
+	for i, message := range results {
+		if errs[i] == nil {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) == 0 {
+		return nil, errs[0]
+	}
+	return messages, nil
+}
+
+func uniqueMessages(messages []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, m := range messages {
+		m = strings.TrimSpace(m)
+		if m == "" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		out = append(out, m)
+	}
+	return out
+}
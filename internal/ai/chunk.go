@@ -0,0 +1,356 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-goll/aigit/internal/git"
+)
+
+// maxParallelChunks bounds concurrent provider requests during map-reduce.
+const maxParallelChunks = 4
+
+// completer is the raw request every provider client implements.
+type completer interface {
+	call(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any) (string, error)
+}
+
+// chunkingClient sends diffs that fit the model's context window straight to
+// the provider. Larger diffs are split by file and hunk: for commit messages
+// each part is summarised in parallel and the summaries are synthesised into
+// one message; for reviews each part is reviewed and the findings merged.
+type chunkingClient struct {
+	Client
+	completer   completer
+	model       string
+	maxTokensIn int
+	prompts     Prompts
+
+	// summaries caches the summaries of oversized diffs, so refining a
+	// message does not summarise the same diff again.
+	mu        sync.Mutex
+	summaries map[summaryKey]string
+}
+
+type summaryKey struct {
+	diff, language string
+}
+
+func newChunkingClient(client Client, model string, maxTokensIn int, prompts Prompts) Client {
+	c, ok := client.(completer)
+	if !ok {
+		return client
+	}
+	return &chunkingClient{Client: client, completer: c, model: model, maxTokensIn: maxTokensIn, prompts: prompts}
+}
+
+func (c *chunkingClient) GenerateCommitMessage(ctx context.Context, diff, language string) (string, error) {
+	budget := InputBudget(c.model, c.prompts.commitPrompt(language), c.maxTokensIn)
+	if EstimateTokens(c.model, diff) <= budget {
+		return c.Client.GenerateCommitMessage(ctx, diff, language)
+	}
+	return c.synthesizeCommitMessage(ctx, diff, language, budget)
+}
+
+// RefineCommitMessage continues the conversation for an oversized diff from
+// its summaries, as the synthesis step saw them.
+func (c *chunkingClient) RefineCommitMessage(ctx context.Context, diff, language string, history []Message, instruction string) (string, error) {
+	budget := InputBudget(c.model, c.prompts.commitPrompt(language), c.maxTokensIn)
+	if EstimateTokens(c.model, diff) <= budget {
+		return c.Client.RefineCommitMessage(ctx, diff, language, history, instruction)
+	}
+	combined, err := c.summarizeDiff(ctx, diff, language, budget)
+	if err != nil {
+		return "", err
+	}
+	return c.completer.call(ctx, c.prompts.synthesisPrompt(language), refineConversation(combined, language, history, instruction), nil)
+}
+
+func (c *chunkingClient) ReviewCode(ctx context.Context, diff, language string) (*ReviewResult, error) {
+	budget := InputBudget(c.model, c.prompts.reviewPrompt(language), c.maxTokensIn)
+	if EstimateTokens(c.model, diff) <= budget {
+		return c.Client.ReviewCode(ctx, diff, language)
+	}
+	return c.mergedReview(ctx, diff, language, budget)
+}
+
+func (c *chunkingClient) StreamCommitMessage(ctx context.Context, diff, language string, onToken TokenFunc) (string, error) {
+	budget := InputBudget(c.model, c.prompts.commitPrompt(language), c.maxTokensIn)
+	if s, ok := c.Client.(Streamer); ok && EstimateTokens(c.model, diff) <= budget {
+		return s.StreamCommitMessage(ctx, diff, language, onToken)
+	}
+	message, err := c.GenerateCommitMessage(ctx, diff, language)
+	if err == nil {
+		onToken(message)
+	}
+	return message, err
+}
+
+func (c *chunkingClient) StreamReviewCode(ctx context.Context, diff, language string, onToken TokenFunc) (*ReviewResult, error) {
+	budget := InputBudget(c.model, c.prompts.reviewPrompt(language), c.maxTokensIn)
+	if s, ok := c.Client.(Streamer); ok && EstimateTokens(c.model, diff) <= budget {
+		return s.StreamReviewCode(ctx, diff, language, onToken)
+	}
+	return c.ReviewCode(ctx, diff, language)
+}
+
+// GenerateCommitMessages summarises an oversized diff once and synthesises
+// each candidate from the same summaries.
+func (c *chunkingClient) GenerateCommitMessages(ctx context.Context, diff, language string, n int) ([]string, error) {
+	budget := InputBudget(c.model, c.prompts.commitPrompt(language), c.maxTokensIn)
+	if EstimateTokens(c.model, diff) <= budget {
+		return GenerateCommitMessages(ctx, c.Client, diff, language, n)
+	}
+	combined, err := c.summarizeDiff(ctx, diff, language, budget)
+	if err != nil {
+		return nil, err
+	}
+	return parallelCandidates(ctx, n, func(ctx context.Context) (string, error) {
+		return c.completer.call(ctx, c.prompts.synthesisPrompt(language), userTurn(combined), nil)
+	})
+}
+
+func (c *chunkingClient) synthesizeCommitMessage(ctx context.Context, diff, language string, budget int) (string, error) {
+	combined, err := c.summarizeDiff(ctx, diff, language, budget)
+	if err != nil {
+		return "", err
+	}
+	return c.completer.call(ctx, c.prompts.synthesisPrompt(language), userTurn(combined), nil)
+}
+
+// summarizeDiff condenses diff into per-part summaries that together fit
+// within budget.
+func (c *chunkingClient) summarizeDiff(ctx context.Context, diff, language string, budget int) (string, error) {
+	key := summaryKey{diff, language}
+	c.mu.Lock()
+	combined, ok := c.summaries[key]
+	c.mu.Unlock()
+	if ok {
+		return combined, nil
+	}
+
+	summaryBudget := InputBudget(c.model, getSummaryPrompt(language), c.maxTokensIn)
+	parts := splitDiff(c.model, diff, summaryBudget)
+	logf("Diff is too large for %s (~%d tokens, budget %d); summarising %d parts...\n",
+		c.model, EstimateTokens(c.model, diff), budget, len(parts))
+
+	summaries, err := c.mapParts(ctx, parts, func(ctx context.Context, part string) (string, error) {
+		return c.completer.call(ctx, getSummaryPrompt(language), userTurn(part), nil)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarise diff: %w", err)
+	}
+
+	// Summaries of a very large change may themselves not fit; fold them
+	// until they do.
+	combined = strings.Join(summaries, "\n\n")
+	for round := 0; EstimateTokens(c.model, combined) > budget; round++ {
+		if round == 2 {
+			combined = truncateToTokens(c.model, combined, budget)
+			break
+		}
+		groups := splitText(c.model, combined, summaryBudget)
+		summaries, err = c.mapParts(ctx, groups, func(ctx context.Context, group string) (string, error) {
+			return c.completer.call(ctx, getSummaryPrompt(language), userTurn(group), nil)
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to summarise diff: %w", err)
+		}
+		combined = strings.Join(summaries, "\n\n")
+	}
+
+	c.mu.Lock()
+	if c.summaries == nil {
+		c.summaries = make(map[summaryKey]string)
+	}
+	c.summaries[key] = combined
+	c.mu.Unlock()
+	return combined, nil
+}
+
+func (c *chunkingClient) mergedReview(ctx context.Context, diff, language string, budget int) (*ReviewResult, error) {
+	parts := splitDiff(c.model, diff, budget)
+	logf("Diff is too large for %s (~%d tokens, budget %d); reviewing %d parts...\n",
+		c.model, EstimateTokens(c.model, diff), budget, len(parts))
+
+	texts, err := c.mapParts(ctx, parts, func(ctx context.Context, part string) (string, error) {
+		return c.completer.call(ctx, c.prompts.reviewPrompt(language), userTurn(part), reviewSchema)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &ReviewResult{}
+	var summaries []string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		result, err := ParseReview(text)
+		if err != nil {
+			return nil, err
+		}
+		if result.Summary != "" {
+			summaries = append(summaries, result.Summary)
+		}
+		for _, f := range result.Findings {
+			key := fmt.Sprintf("%s:%d:%s", f.File, f.StartLine, strings.ToLower(f.Title))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged.Findings = append(merged.Findings, f)
+		}
+	}
+	merged.Summary = strings.Join(summaries, " ")
+	return merged, nil
+}
+
+// mapParts runs fn over parts with bounded concurrency and returns the
+// results in input order. The first error cancels the remaining requests.
+func (c *chunkingClient) mapParts(ctx context.Context, parts []string, fn func(context.Context, string) (string, error)) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]string, len(parts))
+	sem := make(chan struct{}, maxParallelChunks)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i, part := range parts {
+		wg.Add(1)
+		go func(i int, part string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			out, err := fn(ctx, part)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = strings.TrimSpace(out)
+		}(i, part)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// splitDiff cuts diff into parts of at most budget tokens. Whole files are
+// kept together where possible, oversized files are split between hunks and
+// oversized hunks are truncated. Any text before the first file (such as
+// commit messages added as context) is repeated at the top of every part.
+func splitDiff(model, diff string, budget int) []string {
+	preamble := diff
+	if i := strings.Index(diff, "diff --git "); i >= 0 {
+		preamble = diff[:i]
+	}
+	files := git.ParseDiff(diff)
+	if len(files) == 0 {
+		return splitText(model, diff, budget)
+	}
+
+	preamble = truncateToTokens(model, preamble, budget/4)
+	partBudget := budget - EstimateTokens(model, preamble)
+
+	var pieces []string
+	for _, f := range files {
+		whole := f.String()
+		if EstimateTokens(model, whole) <= partBudget {
+			pieces = append(pieces, whole)
+			continue
+		}
+
+		header := strings.Join(f.Header, "\n") + "\n"
+		hunkBudget := partBudget - EstimateTokens(model, header)
+		current := header
+		for _, h := range f.Hunks {
+			hunk := truncateToTokens(model, h.String(), hunkBudget)
+			if current != header && EstimateTokens(model, current+hunk) > partBudget {
+				pieces = append(pieces, current)
+				current = header
+			}
+			current += hunk
+		}
+		pieces = append(pieces, current)
+	}
+
+	return packPieces(model, pieces, partBudget, preamble)
+}
+
+// splitText cuts arbitrary text into parts of at most budget tokens at
+// paragraph boundaries.
+func splitText(model, text string, budget int) []string {
+	var pieces []string
+	for _, p := range strings.Split(text, "\n\n") {
+		pieces = append(pieces, truncateToTokens(model, p, budget)+"\n\n")
+	}
+	return packPieces(model, pieces, budget, "")
+}
+
+func packPieces(model string, pieces []string, budget int, prefix string) []string {
+	var parts []string
+	var current strings.Builder
+	used := 0
+	for _, p := range pieces {
+		n := EstimateTokens(model, p)
+		if used > 0 && used+n > budget {
+			parts = append(parts, prefix+current.String())
+			current.Reset()
+			used = 0
+		}
+		current.WriteString(p)
+		used += n
+	}
+	if used > 0 {
+		parts = append(parts, prefix+current.String())
+	}
+	return parts
+}
+
+// truncateToTokens shortens text to roughly budget tokens, cutting at a line
+// boundary and noting how much was dropped.
+func truncateToTokens(model, text string, budget int) string {
+	n := EstimateTokens(model, text)
+	if n <= budget || budget <= 0 {
+		return text
+	}
+
+	runes := []rune(text)
+	keep := len(runes) * budget / n
+	cut := string(runes[:keep])
+	if i := strings.LastIndex(cut, "\n"); i > 0 {
+		cut = cut[:i+1]
+	}
+	dropped := strings.Count(text[len(cut):], "\n")
+	return cut + fmt.Sprintf("... (%d lines truncated)\n", dropped)
+}
+
+// logOut receives progress and retry warnings.
+var logOut io.Writer = os.Stderr
+
+// SetLogOutput redirects progress and retry warnings, for example to
+// io.Discard where they would only be noise.
+func SetLogOutput(w io.Writer) {
+	logOut = w
+}
+
+func logf(format string, args ...any) {
+	fmt.Fprintf(logOut, format, args...)
+}
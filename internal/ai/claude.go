@@ -7,17 +7,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/go-goll/aigit/internal/config"
 )
 
 type ClaudeClient struct {
-	apiKey  string
-	model   string
-	baseURL string
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+	prompts    Prompts
 }
 
-func NewClaudeClient(cfg *config.Config) (*ClaudeClient, error) {
+func NewClaudeClient(cfg *config.Config, prompts Prompts) (*ClaudeClient, error) {
 	model := cfg.Model
 	if model == "" {
 		model = config.GetDefaultModel(config.ProviderClaude)
@@ -28,18 +31,41 @@ func NewClaudeClient(cfg *config.Config) (*ClaudeClient, error) {
 		baseURL = "https://api.anthropic.com/v1"
 	}
 
+	httpClient, err := NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ClaudeClient{
-		apiKey:  cfg.APIKey,
-		model:   model,
-		baseURL: baseURL,
+		apiKey:     cfg.APIKey,
+		model:      model,
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		prompts:    prompts,
 	}, nil
 }
 
 type claudeRequest struct {
-	Model     string           `json:"model"`
-	MaxTokens int              `json:"max_tokens"`
-	System    string           `json:"system,omitempty"`
-	Messages  []claudeMessage  `json:"messages"`
+	Model      string            `json:"model"`
+	MaxTokens  int               `json:"max_tokens"`
+	System     string            `json:"system,omitempty"`
+	Messages   []claudeMessage   `json:"messages"`
+	Tools      []claudeTool      `json:"tools,omitempty"`
+	ToolChoice *claudeToolChoice `json:"tool_choice,omitempty"`
+	Stream     bool              `json:"stream,omitempty"`
+}
+
+// Claude has no response_format; structured output is obtained by forcing
+// a single tool call whose input schema is the desired response schema.
+type claudeTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type claudeToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
 }
 
 type claudeMessage struct {
@@ -49,38 +75,72 @@ type claudeMessage struct {
 
 type claudeResponse struct {
 	Content []struct {
-		Text string `json:"text"`
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input"`
 	} `json:"content"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
 }
 
-func (c *ClaudeClient) call(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+type claudeStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+const claudeReviewTool = "report_review"
+
+func (c *ClaudeClient) newRequest(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, stream bool) (*http.Request, error) {
 	reqBody := claudeRequest{
 		Model:     c.model,
 		MaxTokens: 4096,
 		System:    systemPrompt,
-		Messages: []claudeMessage{
-			{Role: "user", Content: userPrompt},
-		},
+		Stream:    stream,
+	}
+	for _, m := range messages {
+		reqBody.Messages = append(reqBody.Messages, claudeMessage{Role: string(m.Role), Content: m.Content})
+	}
+	if schema != nil {
+		reqBody.Tools = []claudeTool{{
+			Name:        claudeReviewTool,
+			Description: "Report the code review result.",
+			InputSchema: schema,
+		}}
+		reqBody.ToolChoice = &claudeToolChoice{Type: "tool", Name: claudeReviewTool}
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/messages", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", c.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
+	return req, nil
+}
 
-	resp, err := http.DefaultClient.Do(req)
+func (c *ClaudeClient) call(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any) (string, error) {
+	req, err := c.newRequest(ctx, systemPrompt, messages, schema, false)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := doRequest(c.httpClient, "Claude", req)
 	if err != nil {
 		return "", err
 	}
@@ -92,7 +152,7 @@ func (c *ClaudeClient) call(ctx context.Context, systemPrompt, userPrompt string
 	}
 
 	var result claudeResponse
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := decodeResponse("Claude", resp, body, &result); err != nil {
 		return "", err
 	}
 
@@ -104,13 +164,94 @@ func (c *ClaudeClient) call(ctx context.Context, systemPrompt, userPrompt string
 		return "", fmt.Errorf("no response from Claude")
 	}
 
+	for _, block := range result.Content {
+		if block.Type == "tool_use" && len(block.Input) > 0 {
+			return string(block.Input), nil
+		}
+	}
+
 	return result.Content[0].Text, nil
 }
 
+func (c *ClaudeClient) stream(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, onToken TokenFunc) (string, error) {
+	req, err := c.newRequest(ctx, systemPrompt, messages, schema, true)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := doRequest(c.httpClient, "Claude", req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	err = readSSE(resp.Body, func(event, data string) error {
+		var ev claudeStreamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return err
+		}
+		switch ev.Type {
+		case "error":
+			if ev.Error != nil {
+				// Errors after the response started, typically
+				// overloaded_error, arrive as events with HTTP 200.
+				return &APIError{
+					Provider: "Claude",
+					Kind:     classify(0, ev.Error.Type, ev.Error.Message),
+					Message:  ev.Error.Message,
+				}
+			}
+			return fmt.Errorf("Claude API error: stream aborted")
+		case "content_block_delta":
+			// Tool input arrives as input_json_delta fragments; plain
+			// answers as text_delta.
+			token := ev.Delta.Text
+			if ev.Delta.Type == "input_json_delta" {
+				token = ev.Delta.PartialJSON
+			}
+			if token != "" {
+				text.WriteString(token)
+				onToken(token)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response from Claude")
+	}
+	return text.String(), nil
+}
+
 func (c *ClaudeClient) GenerateCommitMessage(ctx context.Context, diff, language string) (string, error) {
-	return c.call(ctx, getCommitPrompt(language), diff)
+	return c.call(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil)
+}
+
+func (c *ClaudeClient) RefineCommitMessage(ctx context.Context, diff, language string, history []Message, instruction string) (string, error) {
+	return c.call(ctx, c.prompts.commitPrompt(language), refineConversation(diff, language, history, instruction), nil)
+}
+
+func (c *ClaudeClient) ReviewCode(ctx context.Context, diff, language string) (*ReviewResult, error) {
+	text, err := c.call(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
+}
+
+func (c *ClaudeClient) StreamCommitMessage(ctx context.Context, diff, language string, onToken TokenFunc) (string, error) {
+	return c.stream(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil, onToken)
 }
 
-func (c *ClaudeClient) ReviewCode(ctx context.Context, diff, language string) (string, error) {
-	return c.call(ctx, getReviewPrompt(language), diff)
+func (c *ClaudeClient) StreamReviewCode(ctx context.Context, diff, language string, onToken TokenFunc) (*ReviewResult, error) {
+	text, err := c.stream(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema, onToken)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
 }
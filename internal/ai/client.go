@@ -8,20 +8,125 @@ import (
 
 type Client interface {
 	GenerateCommitMessage(ctx context.Context, diff, language string) (string, error)
-	ReviewCode(ctx context.Context, diff, language string) (string, error)
+	// RefineCommitMessage continues the conversation that produced a commit
+	// message for diff. history holds the earlier turns, oldest first: the
+	// first generated message, then each instruction and the answer to it.
+	RefineCommitMessage(ctx context.Context, diff, language string, history []Message, instruction string) (string, error)
+	ReviewCode(ctx context.Context, diff, language string) (*ReviewResult, error)
 }
 
-func NewClient(cfg *config.Config) (Client, error) {
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is one turn of a conversation. The system prompt is passed
+// separately.
+type Message struct {
+	Role    Role
+	Content string
+}
+
+func userTurn(text string) []Message {
+	return []Message{{Role: RoleUser, Content: text}}
+}
+
+// refineConversation rebuilds a commit message conversation: the diff, the
+// earlier turns and the new instruction, with every instruction wrapped so
+// the model answers with a commit message only.
+func refineConversation(diff, language string, history []Message, instruction string) []Message {
+	messages := userTurn(diff)
+	for _, m := range history {
+		if m.Role == RoleUser {
+			m.Content = getRefinePrompt(language, m.Content)
+		}
+		messages = append(messages, m)
+	}
+	return append(messages, Message{Role: RoleUser, Content: getRefinePrompt(language, instruction)})
+}
+
+// TokenFunc receives response text as the provider generates it.
+type TokenFunc func(token string)
+
+// Streamer is implemented by clients that can deliver a response
+// incrementally. The returned value is the same as the non-streaming
+// method would return.
+type Streamer interface {
+	StreamCommitMessage(ctx context.Context, diff, language string, onToken TokenFunc) (string, error)
+	StreamReviewCode(ctx context.Context, diff, language string, onToken TokenFunc) (*ReviewResult, error)
+}
+
+var (
+	_ Streamer = (*OpenAIClient)(nil)
+	_ Streamer = (*ClaudeClient)(nil)
+	_ Streamer = (*GoogleClient)(nil)
+	_ Streamer = (*OpenRouterClient)(nil)
+	_ Streamer = (*OllamaClient)(nil)
+	_ Streamer = (*chunkingClient)(nil)
+	_ Streamer = (*retryClient)(nil)
+	_ Streamer = (*fallbackClient)(nil)
+)
+
+// NewClient returns the client for the configured provider. Diffs larger
+// than the model's input budget are split and summarised transparently,
+// failed requests are retried when the error allows it, and the configured
+// fallbacks are tried in order when the provider keeps failing. Every
+// provider in the chain uses prompts.
+func NewClient(cfg *config.Config, prompts Prompts) (Client, error) {
+	client, err := newClient(cfg, prompts)
+	if err != nil {
+		return nil, err
+	}
+
+	fallbacks, err := cfg.Fallbacks()
+	if err != nil {
+		return nil, err
+	}
+	if len(fallbacks) == 0 {
+		return client, nil
+	}
+	return newFallbackClient(cfg, client, fallbacks, prompts), nil
+}
+
+func newClient(cfg *config.Config, prompts Prompts) (Client, error) {
+	apiKey, err := cfg.ResolveAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	resolved := *cfg
+	resolved.APIKey = apiKey
+	cfg = &resolved
+
+	client, err := newProviderClient(cfg, prompts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newChunkingClient(newRetryClient(client), modelName(cfg), cfg.MaxTokensIn, prompts), nil
+}
+
+func modelName(cfg *config.Config) string {
+	if cfg.Model != "" {
+		return cfg.Model
+	}
+	return config.GetDefaultModel(cfg.Provider)
+}
+
+func newProviderClient(cfg *config.Config, prompts Prompts) (Client, error) {
 	switch cfg.Provider {
 	case config.ProviderOpenAI:
-		return NewOpenAIClient(cfg)
+		return NewOpenAIClient(cfg, prompts)
 	case config.ProviderClaude:
-		return NewClaudeClient(cfg)
+		return NewClaudeClient(cfg, prompts)
 	case config.ProviderGoogle:
-		return NewGoogleClient(cfg)
+		return NewGoogleClient(cfg, prompts)
 	case config.ProviderOpenRouter:
-		return NewOpenRouterClient(cfg)
+		return NewOpenRouterClient(cfg, prompts)
+	case config.ProviderOllama:
+		return NewOllamaClient(cfg, prompts)
 	default:
-		return NewOpenAIClient(cfg)
+		return NewOpenAIClient(cfg, prompts)
 	}
 }
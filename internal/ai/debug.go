@@ -0,0 +1,174 @@
+package ai
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-goll/aigit/internal/redact"
+)
+
+// debugBodyLimit caps how much of each request and response body is logged.
+const debugBodyLimit = 16 * 1024
+
+var (
+	debugMu  sync.Mutex
+	debugOut io.Writer
+	debugSeq atomic.Int64
+	// debugRedactor masks credentials that appear in payloads, such as keys
+	// pasted into a diff.
+	debugRedactor, _ = redact.New(nil)
+)
+
+// sensitiveHeaders are replaced by a marker in debug logs.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"X-Api-Key":           true,
+	"X-Goog-Api-Key":      true,
+	"Api-Key":             true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// EnableDebug logs every provider request and response, with credentials
+// masked, to w. Passing nil turns logging off.
+func EnableDebug(w io.Writer) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	debugOut = w
+}
+
+func debugEnabled() bool {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	return debugOut != nil
+}
+
+func debugf(format string, args ...any) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	if debugOut != nil {
+		fmt.Fprintf(debugOut, format, args...)
+	}
+}
+
+// debugRequest logs req and returns its sequence number, which ties the
+// request to its response when requests run in parallel. It returns 0 when
+// debugging is off.
+func debugRequest(provider string, req *http.Request) int64 {
+	if !debugEnabled() {
+		return 0
+	}
+	id := debugSeq.Add(1)
+
+	var body []byte
+	if req.GetBody != nil {
+		if r, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(r)
+			r.Close()
+		}
+	}
+
+	debugf("[debug #%d] %s → %s %s\n%s%s\n", id, provider, req.Method, maskURL(req.URL),
+		formatHeaders(req.Header), formatBody(body))
+	return id
+}
+
+// debugResponse logs the status and timing of a response and wraps its
+// body so the payload is logged once the caller has read it.
+func debugResponse(id int64, provider string, resp *http.Response, err error, start time.Time) {
+	if id == 0 {
+		return
+	}
+	elapsed := time.Since(start)
+	if err != nil {
+		debugf("[debug #%d] %s ← error after %s: %v\n", id, provider, elapsed.Round(time.Millisecond), err)
+		return
+	}
+	debugf("[debug #%d] %s ← %s in %s\n%s", id, provider, resp.Status, elapsed.Round(time.Millisecond),
+		formatHeaders(resp.Header))
+	resp.Body = &debugBody{ReadCloser: resp.Body, id: id, provider: provider, start: start}
+}
+
+// debugBody records what the caller reads and logs it, with the total
+// time, when the body is closed. For streams this covers the whole stream.
+type debugBody struct {
+	io.ReadCloser
+	id       int64
+	provider string
+	start    time.Time
+	buf      bytes.Buffer
+	n        int64
+	once     sync.Once
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if room := debugBodyLimit + 1 - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	b.once.Do(func() {
+		debugf("[debug #%d] %s ← body (%d bytes, %s total)\n%s\n", b.id, b.provider, b.n,
+			time.Since(b.start).Round(time.Millisecond), formatBody(b.buf.Bytes()))
+	})
+	return b.ReadCloser.Close()
+}
+
+func maskURL(u *url.URL) string {
+	masked := *u
+	q := masked.Query()
+	for _, key := range []string{"key", "api_key", "token"} {
+		if q.Has(key) {
+			q.Set(key, "***")
+		}
+	}
+	masked.RawQuery = q.Encode()
+	return masked.Redacted()
+}
+
+func formatHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		value := strings.Join(h[name], ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			value = "***"
+		}
+		fmt.Fprintf(&b, "  %s: %s\n", name, value)
+	}
+	return b.String()
+}
+
+func formatBody(body []byte) string {
+	if len(body) == 0 {
+		return "  (empty body)"
+	}
+	text := string(body)
+	suffix := ""
+	if len(text) > debugBodyLimit {
+		text = text[:debugBodyLimit]
+		suffix = fmt.Sprintf("\n  ... (truncated, %d bytes shown)", debugBodyLimit)
+	}
+	if debugRedactor != nil {
+		text, _ = debugRedactor.Redact(text)
+	}
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ") + suffix
+}
@@ -0,0 +1,271 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind classifies a failed provider request.
+type ErrorKind string
+
+const (
+	ErrAuth          ErrorKind = "auth"           // invalid or missing API key, no access to the model
+	ErrRateLimit     ErrorKind = "rate_limit"     // too many requests; retry after a pause
+	ErrQuota         ErrorKind = "quota"          // out of credits or billing quota
+	ErrContextLength ErrorKind = "context_length" // input too large for the model
+	ErrServer        ErrorKind = "server"         // 5xx or overloaded
+	ErrNetwork       ErrorKind = "network"        // no response at all
+	ErrBadRequest    ErrorKind = "bad_request"    // any other rejected request
+	ErrBadResponse   ErrorKind = "bad_response"   // success status but an unreadable body
+)
+
+// requestIDHeaders are the response headers providers and common proxies
+// use to identify a request in support tickets and logs.
+var requestIDHeaders = []string{"x-request-id", "request-id", "x-goog-request-id", "cf-ray"}
+
+// APIError is returned for requests a provider rejected or that never
+// reached it.
+type APIError struct {
+	Provider   string
+	Kind       ErrorKind
+	StatusCode int // 0 for network errors and errors sent inside a stream
+	Message    string
+	// RetryAfter is the delay the server asked for, if any.
+	RetryAfter time.Duration
+	RequestID  string
+	// Snippet is the start of a response body that was not a provider
+	// error object, such as an HTML page from a proxy.
+	Snippet string
+	Err     error
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	if e.StatusCode == 0 {
+		fmt.Fprintf(&b, "%s API error (%s): %s", e.Provider, e.Kind, e.Message)
+	} else {
+		fmt.Fprintf(&b, "%s API error (%s, HTTP %d): %s", e.Provider, e.Kind, e.StatusCode, e.Message)
+	}
+	if e.Snippet != "" {
+		fmt.Fprintf(&b, ": %s", e.Snippet)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request id: %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+func (e *APIError) Unwrap() error { return e.Err }
+
+// Retryable reports whether repeating the same request may succeed.
+func (e *APIError) Retryable() bool {
+	switch e.Kind {
+	case ErrRateLimit, ErrServer, ErrNetwork:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRetryable reports whether err is an APIError worth retrying.
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Retryable()
+}
+
+// doRequest sends req with client and turns transport failures and non-2xx responses
+// into APIErrors. On success the caller owns the response body.
+func doRequest(client *http.Client, provider string, req *http.Request) (*http.Response, error) {
+	id := debugRequest(provider, req)
+	start := time.Now()
+	resp, err := client.Do(req)
+	debugResponse(id, provider, resp, err, start)
+	if err != nil {
+		// Cancellation and deadlines are the caller's decision, not a
+		// provider failure.
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &APIError{Provider: provider, Kind: ErrNetwork, Message: err.Error(), Err: err}
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return nil, newAPIError(provider, resp, body)
+}
+
+func newAPIError(provider string, resp *http.Response, body []byte) *APIError {
+	message, errType := parseErrorBody(body)
+	var snippet string
+	if message == "" {
+		message = resp.Status
+		if snippet = bodySnippet(body); strings.Contains(message, snippet) {
+			snippet = ""
+		}
+	}
+	return &APIError{
+		Provider:   provider,
+		Kind:       classify(resp.StatusCode, errType, message),
+		StatusCode: resp.StatusCode,
+		Message:    message,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		RequestID:  requestID(resp),
+		Snippet:    snippet,
+	}
+}
+
+// decodeResponse unmarshals a successful response body into v. A body that
+// is not JSON, such as a proxy's HTML page, is reported with a snippet
+// rather than as a bare parse error.
+func decodeResponse(provider string, resp *http.Response, body []byte, v any) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return &APIError{
+			Provider:   provider,
+			Kind:       ErrBadResponse,
+			StatusCode: resp.StatusCode,
+			Message:    "unexpected response (" + resp.Header.Get("Content-Type") + ")",
+			RequestID:  requestID(resp),
+			Snippet:    bodySnippet(body),
+			Err:        err,
+		}
+	}
+	return nil
+}
+
+func requestID(resp *http.Response) string {
+	for _, h := range requestIDHeaders {
+		if id := resp.Header.Get(h); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+var (
+	htmlTitleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlTagRe   = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// bodySnippet condenses a response body for an error message: the title of
+// an HTML page, or the first 200 characters of anything else.
+func bodySnippet(body []byte) string {
+	text := string(body)
+	if m := htmlTitleRe.FindStringSubmatch(text); m != nil {
+		text = m[1]
+	} else if strings.Contains(text, "<html") || strings.Contains(text, "<HTML") {
+		text = htmlTagRe.ReplaceAllString(text, " ")
+	}
+	return truncate(strings.Join(strings.Fields(text), " "), 200)
+}
+
+// parseErrorBody extracts the message and error type from the error
+// bodies of the supported providers:
+//
+//	OpenAI, OpenRouter: {"error": {"message", "type", "code"}}
+//	Claude:             {"type": "error", "error": {"type", "message"}}
+//	Google:             {"error": {"code", "message", "status"}}
+//	Ollama:             {"error": "message"}
+func parseErrorBody(body []byte) (message, errType string) {
+	var parsed struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) != nil || len(parsed.Error) == 0 {
+		return "", ""
+	}
+
+	var text string
+	if json.Unmarshal(parsed.Error, &text) == nil {
+		return text, ""
+	}
+
+	var obj struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Status  string `json:"status"`
+		Code    any    `json:"code"`
+	}
+	if json.Unmarshal(parsed.Error, &obj) != nil {
+		return "", ""
+	}
+	errType = obj.Type
+	if errType == "" {
+		errType = obj.Status
+	}
+	if code, ok := obj.Code.(string); ok && code != "" {
+		errType += " " + code
+	}
+	return obj.Message, strings.TrimSpace(errType)
+}
+
+func classify(status int, errType, message string) ErrorKind {
+	text := strings.ToLower(errType + " " + message)
+	containsAny := func(words ...string) bool {
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ErrAuth
+	case status == http.StatusPaymentRequired:
+		return ErrQuota
+	case status == http.StatusTooManyRequests:
+		if containsAny("insufficient_quota", "billing", "credit", "exceeded your current quota") {
+			return ErrQuota
+		}
+		return ErrRateLimit
+	case status == http.StatusRequestEntityTooLarge:
+		return ErrContextLength
+	case status == http.StatusRequestTimeout, status >= 500:
+		// Includes Anthropic's 529 "overloaded".
+		return ErrServer
+	case containsAny("context length", "context_length", "context window", "too many tokens", "prompt is too long", "maximum context", "input is too long"):
+		return ErrContextLength
+	case containsAny("api key not valid", "invalid api key", "invalid_api_key", "authentication"):
+		return ErrAuth
+	// Errors sent inside a stream carry a type but no status code.
+	case containsAny("rate_limit"):
+		return ErrRateLimit
+	case containsAny("overloaded", "api_error"):
+		return ErrServer
+	default:
+		return ErrBadRequest
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// isContextError reports whether err comes from the caller's context rather
+// than from the provider.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
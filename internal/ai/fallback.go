@@ -0,0 +1,154 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-goll/aigit/internal/config"
+)
+
+// fallbackEntry is one provider in the fallback chain. Its client is built
+// on first use, so the keys of unused fallbacks are never resolved.
+type fallbackEntry struct {
+	cfg    *config.Config
+	client Client
+}
+
+func (e *fallbackEntry) name() string {
+	label := fmt.Sprintf("%s/%s", e.cfg.Provider, modelName(e.cfg))
+	if p := e.cfg.ActiveProfile(); p != "" {
+		label += " (profile " + p + ")"
+	}
+	return label
+}
+
+// fallbackClient tries each entry in order until one answers. It moves on
+// after provider errors (once retries are exhausted) and when a fallback
+// cannot be set up, for example because its key is missing; cancellation
+// and local errors stop the chain.
+type fallbackClient struct {
+	entries []*fallbackEntry
+	prompts Prompts
+}
+
+func newFallbackClient(primaryCfg *config.Config, primary Client, fallbacks []*config.Config, prompts Prompts) *fallbackClient {
+	entries := []*fallbackEntry{{cfg: primaryCfg, client: primary}}
+	for _, cfg := range fallbacks {
+		entries = append(entries, &fallbackEntry{cfg: cfg})
+	}
+	return &fallbackClient{entries: entries, prompts: prompts}
+}
+
+// run calls fn with each client in turn. stop reports whether output was
+// already delivered, in which case switching providers would duplicate it.
+func (f *fallbackClient) run(fn func(Client) error, stop func() bool) error {
+	var lastErr error
+	for i, e := range f.entries {
+		if e.client == nil {
+			client, err := newClient(e.cfg, f.prompts)
+			if err != nil {
+				logf("⚠ Skipping fallback %s: %v\n", e.name(), err)
+				lastErr = err
+				continue
+			}
+			e.client = client
+		}
+
+		err := fn(e.client)
+		if err == nil {
+			if i > 0 {
+				logf("✓ Answered by %s\n", e.name())
+			}
+			return nil
+		}
+		lastErr = err
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || isContextError(err) || (stop != nil && stop()) {
+			return err
+		}
+		if i < len(f.entries)-1 {
+			logf("⚠ %s failed: %v\n", e.name(), err)
+		}
+	}
+	return fmt.Errorf("all providers failed, last error: %w", lastErr)
+}
+
+func (f *fallbackClient) GenerateCommitMessage(ctx context.Context, diff, language string) (string, error) {
+	var message string
+	err := f.run(func(c Client) error {
+		var err error
+		message, err = c.GenerateCommitMessage(ctx, diff, language)
+		return err
+	}, nil)
+	return message, err
+}
+
+func (f *fallbackClient) GenerateCommitMessages(ctx context.Context, diff, language string, n int) ([]string, error) {
+	var messages []string
+	err := f.run(func(c Client) error {
+		var err error
+		messages, err = GenerateCommitMessages(ctx, c, diff, language, n)
+		return err
+	}, nil)
+	return messages, err
+}
+
+func (f *fallbackClient) RefineCommitMessage(ctx context.Context, diff, language string, history []Message, instruction string) (string, error) {
+	var message string
+	err := f.run(func(c Client) error {
+		var err error
+		message, err = c.RefineCommitMessage(ctx, diff, language, history, instruction)
+		return err
+	}, nil)
+	return message, err
+}
+
+func (f *fallbackClient) ReviewCode(ctx context.Context, diff, language string) (*ReviewResult, error) {
+	var result *ReviewResult
+	err := f.run(func(c Client) error {
+		var err error
+		result, err = c.ReviewCode(ctx, diff, language)
+		return err
+	}, nil)
+	return result, err
+}
+
+func (f *fallbackClient) StreamCommitMessage(ctx context.Context, diff, language string, onToken TokenFunc) (string, error) {
+	var message string
+	started := false
+	onStarted := func(token string) {
+		started = true
+		onToken(token)
+	}
+	err := f.run(func(c Client) error {
+		var err error
+		if s, ok := c.(Streamer); ok {
+			message, err = s.StreamCommitMessage(ctx, diff, language, onStarted)
+		} else if message, err = c.GenerateCommitMessage(ctx, diff, language); err == nil {
+			onStarted(message)
+		}
+		return err
+	}, func() bool { return started })
+	return message, err
+}
+
+func (f *fallbackClient) StreamReviewCode(ctx context.Context, diff, language string, onToken TokenFunc) (*ReviewResult, error) {
+	var result *ReviewResult
+	started := false
+	onStarted := func(token string) {
+		started = true
+		onToken(token)
+	}
+	err := f.run(func(c Client) error {
+		var err error
+		if s, ok := c.(Streamer); ok {
+			result, err = s.StreamReviewCode(ctx, diff, language, onStarted)
+		} else {
+			result, err = c.ReviewCode(ctx, diff, language)
+		}
+		return err
+	}, func() bool { return started })
+	return result, err
+}
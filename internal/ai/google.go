@@ -7,17 +7,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/go-goll/aigit/internal/config"
 )
 
 type GoogleClient struct {
-	apiKey  string
-	model   string
-	baseURL string
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+	prompts    Prompts
 }
 
-func NewGoogleClient(cfg *config.Config) (*GoogleClient, error) {
+func NewGoogleClient(cfg *config.Config, prompts Prompts) (*GoogleClient, error) {
 	model := cfg.Model
 	if model == "" {
 		model = config.GetDefaultModel(config.ProviderGoogle)
@@ -28,16 +31,30 @@ func NewGoogleClient(cfg *config.Config) (*GoogleClient, error) {
 		baseURL = "https://generativelanguage.googleapis.com/v1beta"
 	}
 
+	httpClient, err := NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &GoogleClient{
-		apiKey:  cfg.APIKey,
-		model:   model,
-		baseURL: baseURL,
+		apiKey:     cfg.APIKey,
+		model:      model,
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		prompts:    prompts,
 	}, nil
 }
 
 type googleRequest struct {
-	Contents         []googleContent       `json:"contents"`
-	SystemInstruction *googleContent       `json:"systemInstruction,omitempty"`
+	Contents          []googleContent         `json:"contents"`
+	SystemInstruction *googleContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *googleGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type googleGenerationConfig struct {
+	ResponseMimeType string         `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]any `json:"responseSchema,omitempty"`
+	CandidateCount   int            `json:"candidateCount,omitempty"`
 }
 
 type googleContent struct {
@@ -62,63 +79,198 @@ type googleResponse struct {
 	} `json:"error,omitempty"`
 }
 
-func (c *GoogleClient) call(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+// toGoogleSchema converts a JSON schema into the OpenAPI subset Gemini
+// accepts: upper-case type names and no additionalProperties.
+func toGoogleSchema(schema map[string]any) map[string]any {
+	out := make(map[string]any, len(schema))
+	for k, v := range schema {
+		switch k {
+		case "additionalProperties":
+			continue
+		case "type":
+			out[k] = strings.ToUpper(v.(string))
+		case "properties":
+			props := make(map[string]any)
+			for name, prop := range v.(map[string]any) {
+				props[name] = toGoogleSchema(prop.(map[string]any))
+			}
+			out[k] = props
+		case "items":
+			out[k] = toGoogleSchema(v.(map[string]any))
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// newRequest builds a generateContent request, or streamGenerateContent
+// with server-sent events when stream is set. n asks for that many
+// candidates; 0 leaves the default of one.
+func (c *GoogleClient) newRequest(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, stream bool, n int) (*http.Request, error) {
 	reqBody := googleRequest{
 		SystemInstruction: &googleContent{
 			Parts: []googlePart{{Text: systemPrompt}},
 		},
-		Contents: []googleContent{
-			{
-				Role:  "user",
-				Parts: []googlePart{{Text: userPrompt}},
-			},
-		},
+	}
+	for _, m := range messages {
+		// Gemini calls the assistant role "model".
+		role := "user"
+		if m.Role == RoleAssistant {
+			role = "model"
+		}
+		reqBody.Contents = append(reqBody.Contents, googleContent{
+			Role:  role,
+			Parts: []googlePart{{Text: m.Content}},
+		})
+	}
+	if schema != nil || n > 1 {
+		reqBody.GenerationConfig = &googleGenerationConfig{CandidateCount: n}
+	}
+	if schema != nil {
+		reqBody.GenerationConfig.ResponseMimeType = "application/json"
+		reqBody.GenerationConfig.ResponseSchema = toGoogleSchema(schema)
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", c.baseURL, c.model, c.apiKey)
+	url := fmt.Sprintf("%s/models/%s:generateContent", c.baseURL, c.model)
+	if stream {
+		url = fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", c.baseURL, c.model)
+	}
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	// Sent as a header rather than the ?key= parameter so the key does not
+	// appear in URLs quoted by error messages and logs.
+	req.Header.Set("x-goog-api-key", c.apiKey)
+	return req, nil
+}
 
-	resp, err := http.DefaultClient.Do(req)
+func (c *GoogleClient) call(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any) (string, error) {
+	texts, err := c.complete(ctx, systemPrompt, messages, schema, 0)
 	if err != nil {
 		return "", err
 	}
+	return texts[0], nil
+}
+
+// complete returns the text of every candidate in the response.
+func (c *GoogleClient) complete(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, n int) ([]string, error) {
+	req, err := c.newRequest(ctx, systemPrompt, messages, schema, false, n)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doRequest(c.httpClient, "Google", req)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var result googleResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", err
+	if err := decodeResponse("Google", resp, body, &result); err != nil {
+		return nil, err
 	}
 
 	if result.Error != nil {
-		return "", fmt.Errorf("Google API error: %s", result.Error.Message)
+		return nil, fmt.Errorf("Google API error: %s", result.Error.Message)
 	}
 
-	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no response from Google")
+	var texts []string
+	for _, candidate := range result.Candidates {
+		if len(candidate.Content.Parts) > 0 {
+			texts = append(texts, candidate.Content.Parts[0].Text)
+		}
+	}
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no response from Google")
 	}
+	return texts, nil
+}
 
-	return result.Candidates[0].Content.Parts[0].Text, nil
+func (c *GoogleClient) stream(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, onToken TokenFunc) (string, error) {
+	req, err := c.newRequest(ctx, systemPrompt, messages, schema, true, 0)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := doRequest(c.httpClient, "Google", req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	err = readSSE(resp.Body, func(_, data string) error {
+		var chunk googleResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("Google API error: %s", chunk.Error.Message)
+		}
+		if len(chunk.Candidates) == 0 {
+			return nil
+		}
+		for _, part := range chunk.Candidates[0].Content.Parts {
+			if part.Text != "" {
+				text.WriteString(part.Text)
+				onToken(part.Text)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response from Google")
+	}
+	return text.String(), nil
 }
 
 func (c *GoogleClient) GenerateCommitMessage(ctx context.Context, diff, language string) (string, error) {
-	return c.call(ctx, getCommitPrompt(language), diff)
+	return c.call(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil)
+}
+
+// GenerateCommitMessages asks for n candidates in one request.
+func (c *GoogleClient) GenerateCommitMessages(ctx context.Context, diff, language string, n int) ([]string, error) {
+	return c.complete(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil, n)
 }
 
-func (c *GoogleClient) ReviewCode(ctx context.Context, diff, language string) (string, error) {
-	return c.call(ctx, getReviewPrompt(language), diff)
+func (c *GoogleClient) RefineCommitMessage(ctx context.Context, diff, language string, history []Message, instruction string) (string, error) {
+	return c.call(ctx, c.prompts.commitPrompt(language), refineConversation(diff, language, history, instruction), nil)
+}
+
+func (c *GoogleClient) ReviewCode(ctx context.Context, diff, language string) (*ReviewResult, error) {
+	text, err := c.call(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
+}
+
+func (c *GoogleClient) StreamCommitMessage(ctx context.Context, diff, language string, onToken TokenFunc) (string, error) {
+	return c.stream(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil, onToken)
+}
+
+func (c *GoogleClient) StreamReviewCode(ctx context.Context, diff, language string, onToken TokenFunc) (*ReviewResult, error) {
+	text, err := c.stream(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema, onToken)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
 }
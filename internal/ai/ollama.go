@@ -0,0 +1,254 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/go-goll/aigit/internal/config"
+)
+
+type OllamaClient struct {
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+	prompts    Prompts
+}
+
+func NewOllamaClient(cfg *config.Config, prompts Prompts) (*OllamaClient, error) {
+	model := cfg.Model
+	if model == "" {
+		model = config.GetDefaultModel(config.ProviderOllama)
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = config.GetDefaultBaseURL(config.ProviderOllama)
+	}
+
+	httpClient, err := NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OllamaClient{
+		apiKey:     cfg.APIKey,
+		model:      model,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+		prompts:    prompts,
+	}, nil
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   map[string]any  `json:"format,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done  bool   `json:"done"`
+	Error string `json:"error,omitempty"`
+}
+
+func (c *OllamaClient) newRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
+
+func (c *OllamaClient) do(req *http.Request) (*http.Response, error) {
+	resp, err := doRequest(c.httpClient, "Ollama", req)
+	if err != nil {
+		return nil, ollamaConnError(c.baseURL, err)
+	}
+	return resp, nil
+}
+
+// ollamaConnError turns a refused connection into an actionable message.
+func ollamaConnError(baseURL string, err error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && errors.Is(err, syscall.ECONNREFUSED) {
+		apiErr.Message = fmt.Sprintf("cannot reach Ollama at %s: is the daemon running? Start it with 'ollama serve'", baseURL)
+	}
+	return err
+}
+
+func (c *OllamaClient) chat(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, onToken TokenFunc) (string, error) {
+	reqBody := ollamaRequest{
+		Model:    c.model,
+		Messages: []ollamaMessage{{Role: "system", Content: systemPrompt}},
+		Stream:   onToken != nil,
+		Format:   schema,
+	}
+	for _, m := range messages {
+		reqBody.Messages = append(reqBody.Messages, ollamaMessage{Role: string(m.Role), Content: m.Content})
+	}
+
+	req, err := c.newRequest(ctx, "POST", "/api/chat", reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.do(req)
+	// A missing model is not pulled here: downloading gigabytes does not
+	// fit in a command's timeout, let alone a hook's.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound &&
+		strings.Contains(apiErr.Message, "not found") && strings.Contains(apiErr.Message, "model") {
+		apiErr.Message = fmt.Sprintf("model %s is not installed; run 'ollama pull %s' first", c.model, c.model)
+		return "", err
+	}
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Streaming responses are newline-delimited JSON objects; a
+	// non-streaming response is the same object once.
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return "", fmt.Errorf("Ollama API error: unexpected response: %s", truncate(string(line), 200))
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("Ollama API error: %s", chunk.Error)
+		}
+		if token := chunk.Message.Content; token != "" {
+			text.WriteString(token)
+			if onToken != nil {
+				onToken(token)
+			}
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response from Ollama")
+	}
+	return text.String(), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+func (c *OllamaClient) call(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any) (string, error) {
+	return c.chat(ctx, systemPrompt, messages, schema, nil)
+}
+
+func (c *OllamaClient) GenerateCommitMessage(ctx context.Context, diff, language string) (string, error) {
+	return c.call(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil)
+}
+
+func (c *OllamaClient) RefineCommitMessage(ctx context.Context, diff, language string, history []Message, instruction string) (string, error) {
+	return c.call(ctx, c.prompts.commitPrompt(language), refineConversation(diff, language, history, instruction), nil)
+}
+
+func (c *OllamaClient) ReviewCode(ctx context.Context, diff, language string) (*ReviewResult, error) {
+	text, err := c.call(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
+}
+
+func (c *OllamaClient) StreamCommitMessage(ctx context.Context, diff, language string, onToken TokenFunc) (string, error) {
+	return c.chat(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil, onToken)
+}
+
+func (c *OllamaClient) StreamReviewCode(ctx context.Context, diff, language string, onToken TokenFunc) (*ReviewResult, error) {
+	text, err := c.chat(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema, onToken)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
+}
+
+// ListOllamaModels returns the names of the models installed in the Ollama
+// instance at cfg.BaseURL.
+func ListOllamaModels(ctx context.Context, cfg *config.Config) ([]string, error) {
+	httpClient, err := NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = config.GetDefaultBaseURL(config.ProviderOllama)
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doRequest(httpClient, "Ollama", req)
+	if err != nil {
+		return nil, ollamaConnError(baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(result.Models))
+	for _, m := range result.Models {
+		names = append(names, m.Name)
+	}
+	return names, nil
+}
@@ -7,17 +7,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/go-goll/aigit/internal/config"
 )
 
 type OpenAIClient struct {
-	apiKey  string
-	model   string
-	baseURL string
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+	prompts    Prompts
 }
 
-func NewOpenAIClient(cfg *config.Config) (*OpenAIClient, error) {
+func NewOpenAIClient(cfg *config.Config, prompts Prompts) (*OpenAIClient, error) {
 	model := cfg.Model
 	if model == "" {
 		model = config.GetDefaultModel(config.ProviderOpenAI)
@@ -28,16 +31,37 @@ func NewOpenAIClient(cfg *config.Config) (*OpenAIClient, error) {
 		baseURL = "https://api.openai.com/v1"
 	}
 
+	httpClient, err := NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &OpenAIClient{
-		apiKey:  cfg.APIKey,
-		model:   model,
-		baseURL: baseURL,
+		apiKey:     cfg.APIKey,
+		model:      model,
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		prompts:    prompts,
 	}, nil
 }
 
 type openAIRequest struct {
-	Model    string          `json:"model"`
-	Messages []openAIMessage `json:"messages"`
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+	N              int                   `json:"n,omitempty"`
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIJSONSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
 }
 
 type openAIMessage struct {
@@ -56,59 +80,189 @@ type openAIResponse struct {
 	} `json:"error,omitempty"`
 }
 
-func (c *OpenAIClient) call(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
-	reqBody := openAIRequest{
-		Model: c.model,
-		Messages: []openAIMessage{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func newOpenAIResponseFormat(schema map[string]any) *openAIResponseFormat {
+	if schema == nil {
+		return nil
+	}
+	return &openAIResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &openAIJSONSchema{
+			Name:   "code_review",
+			Strict: true,
+			Schema: schema,
 		},
 	}
+}
+
+// openAIMessages prepends the system prompt to a conversation.
+func openAIMessages(systemPrompt string, messages []Message) []openAIMessage {
+	out := []openAIMessage{{Role: "system", Content: systemPrompt}}
+	for _, m := range messages {
+		out = append(out, openAIMessage{Role: string(m.Role), Content: m.Content})
+	}
+	return out
+}
+
+// newRequest builds a chat completion request. n asks for that many
+// alternative completions; 0 leaves the server default of one.
+func (c *OpenAIClient) newRequest(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, stream bool, n int) (*http.Request, error) {
+	reqBody := openAIRequest{
+		Model:          c.model,
+		Messages:       openAIMessages(systemPrompt, messages),
+		ResponseFormat: newOpenAIResponseFormat(schema),
+		Stream:         stream,
+		N:              n,
+	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return req, nil
+}
 
-	resp, err := http.DefaultClient.Do(req)
+func (c *OpenAIClient) call(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any) (string, error) {
+	texts, err := c.complete(ctx, systemPrompt, messages, schema, 0)
 	if err != nil {
 		return "", err
 	}
+	return texts[0], nil
+}
+
+// complete returns the content of every choice in the response.
+func (c *OpenAIClient) complete(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, n int) ([]string, error) {
+	req, err := c.newRequest(ctx, systemPrompt, messages, schema, false, n)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doRequest(c.httpClient, "OpenAI", req)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var result openAIResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", err
+	if err := decodeResponse("OpenAI", resp, body, &result); err != nil {
+		return nil, err
 	}
 
 	if result.Error != nil {
-		return "", fmt.Errorf("OpenAI API error: %s", result.Error.Message)
+		return nil, fmt.Errorf("OpenAI API error: %s", result.Error.Message)
 	}
 
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+		return nil, fmt.Errorf("no response from OpenAI")
 	}
 
-	return result.Choices[0].Message.Content, nil
+	texts := make([]string, len(result.Choices))
+	for i, choice := range result.Choices {
+		texts[i] = choice.Message.Content
+	}
+	return texts, nil
+}
+
+func (c *OpenAIClient) stream(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, onToken TokenFunc) (string, error) {
+	req, err := c.newRequest(ctx, systemPrompt, messages, schema, true, 0)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := doRequest(c.httpClient, "OpenAI", req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return readOpenAIStream(resp.Body, "OpenAI", onToken)
+}
+
+// readOpenAIStream collects the chat.completion.chunk events of an
+// OpenAI-compatible stream.
+func readOpenAIStream(r io.Reader, provider string, onToken TokenFunc) (string, error) {
+	var text strings.Builder
+	err := readSSE(r, func(_, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("%s API error: %s", provider, chunk.Error.Message)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			token := chunk.Choices[0].Delta.Content
+			text.WriteString(token)
+			onToken(token)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response from %s", provider)
+	}
+	return text.String(), nil
 }
 
 func (c *OpenAIClient) GenerateCommitMessage(ctx context.Context, diff, language string) (string, error) {
-	return c.call(ctx, getCommitPrompt(language), diff)
+	return c.call(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil)
+}
+
+// GenerateCommitMessages asks for n alternatives in one request.
+func (c *OpenAIClient) GenerateCommitMessages(ctx context.Context, diff, language string, n int) ([]string, error) {
+	return c.complete(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil, n)
+}
+
+func (c *OpenAIClient) RefineCommitMessage(ctx context.Context, diff, language string, history []Message, instruction string) (string, error) {
+	return c.call(ctx, c.prompts.commitPrompt(language), refineConversation(diff, language, history, instruction), nil)
 }
 
-func (c *OpenAIClient) ReviewCode(ctx context.Context, diff, language string) (string, error) {
-	return c.call(ctx, getReviewPrompt(language), diff)
+func (c *OpenAIClient) ReviewCode(ctx context.Context, diff, language string) (*ReviewResult, error) {
+	text, err := c.call(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
+}
+
+func (c *OpenAIClient) StreamCommitMessage(ctx context.Context, diff, language string, onToken TokenFunc) (string, error) {
+	return c.stream(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil, onToken)
+}
+
+func (c *OpenAIClient) StreamReviewCode(ctx context.Context, diff, language string, onToken TokenFunc) (*ReviewResult, error) {
+	text, err := c.stream(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema, onToken)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
 }
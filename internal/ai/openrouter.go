@@ -12,12 +12,14 @@ import (
 )
 
 type OpenRouterClient struct {
-	apiKey  string
-	model   string
-	baseURL string
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+	prompts    Prompts
 }
 
-func NewOpenRouterClient(cfg *config.Config) (*OpenRouterClient, error) {
+func NewOpenRouterClient(cfg *config.Config, prompts Prompts) (*OpenRouterClient, error) {
 	model := cfg.Model
 	if model == "" {
 		model = config.GetDefaultModel(config.ProviderOpenRouter)
@@ -28,16 +30,25 @@ func NewOpenRouterClient(cfg *config.Config) (*OpenRouterClient, error) {
 		baseURL = config.GetDefaultBaseURL(config.ProviderOpenRouter)
 	}
 
+	httpClient, err := NewHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return &OpenRouterClient{
-		apiKey:  cfg.APIKey,
-		model:   model,
-		baseURL: baseURL,
+		apiKey:     cfg.APIKey,
+		model:      model,
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		prompts:    prompts,
 	}, nil
 }
 
 type openRouterRequest struct {
-	Model    string              `json:"model"`
-	Messages []openRouterMessage `json:"messages"`
+	Model          string                `json:"model"`
+	Messages       []openRouterMessage   `json:"messages"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
 }
 
 type openRouterMessage struct {
@@ -56,31 +67,42 @@ type openRouterResponse struct {
 	} `json:"error,omitempty"`
 }
 
-func (c *OpenRouterClient) call(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+func (c *OpenRouterClient) newRequest(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, stream bool) (*http.Request, error) {
+	reqMessages := []openRouterMessage{{Role: "system", Content: systemPrompt}}
+	for _, m := range messages {
+		reqMessages = append(reqMessages, openRouterMessage{Role: string(m.Role), Content: m.Content})
+	}
 	reqBody := openRouterRequest{
-		Model: c.model,
-		Messages: []openRouterMessage{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
-		},
+		Model:          c.model,
+		Messages:       reqMessages,
+		ResponseFormat: newOpenAIResponseFormat(schema),
+		Stream:         stream,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("HTTP-Referer", "https://github.com/go-goll/aigit")
 	req.Header.Set("X-Title", "aigit")
+	return req, nil
+}
+
+func (c *OpenRouterClient) call(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any) (string, error) {
+	req, err := c.newRequest(ctx, systemPrompt, messages, schema, false)
+	if err != nil {
+		return "", err
+	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := doRequest(c.httpClient, "OpenRouter", req)
 	if err != nil {
 		return "", err
 	}
@@ -92,7 +114,7 @@ func (c *OpenRouterClient) call(ctx context.Context, systemPrompt, userPrompt st
 	}
 
 	var result openRouterResponse
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := decodeResponse("OpenRouter", resp, body, &result); err != nil {
 		return "", err
 	}
 
@@ -107,10 +129,46 @@ func (c *OpenRouterClient) call(ctx context.Context, systemPrompt, userPrompt st
 	return result.Choices[0].Message.Content, nil
 }
 
+func (c *OpenRouterClient) stream(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any, onToken TokenFunc) (string, error) {
+	req, err := c.newRequest(ctx, systemPrompt, messages, schema, true)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := doRequest(c.httpClient, "OpenRouter", req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return readOpenAIStream(resp.Body, "OpenRouter", onToken)
+}
+
 func (c *OpenRouterClient) GenerateCommitMessage(ctx context.Context, diff, language string) (string, error) {
-	return c.call(ctx, getCommitPrompt(language), diff)
+	return c.call(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil)
+}
+
+func (c *OpenRouterClient) RefineCommitMessage(ctx context.Context, diff, language string, history []Message, instruction string) (string, error) {
+	return c.call(ctx, c.prompts.commitPrompt(language), refineConversation(diff, language, history, instruction), nil)
 }
 
-func (c *OpenRouterClient) ReviewCode(ctx context.Context, diff, language string) (string, error) {
-	return c.call(ctx, getReviewPrompt(language), diff)
+func (c *OpenRouterClient) ReviewCode(ctx context.Context, diff, language string) (*ReviewResult, error) {
+	text, err := c.call(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
+}
+
+func (c *OpenRouterClient) StreamCommitMessage(ctx context.Context, diff, language string, onToken TokenFunc) (string, error) {
+	return c.stream(ctx, c.prompts.commitPrompt(language), userTurn(diff), nil, onToken)
+}
+
+func (c *OpenRouterClient) StreamReviewCode(ctx context.Context, diff, language string, onToken TokenFunc) (*ReviewResult, error) {
+	text, err := c.stream(ctx, c.prompts.reviewPrompt(language), userTurn(diff), reviewSchema, onToken)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReview(text)
 }
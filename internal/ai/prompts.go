@@ -1,5 +1,10 @@
 package ai
 
+import (
+	"fmt"
+	"strings"
+)
+
 const commitPromptEN = `You are a helpful assistant that generates git commit messages.
 Based on the git diff provided, generate a concise and descriptive commit message.
 
@@ -34,10 +39,24 @@ Focus on:
 5. Race conditions or concurrency issues
 6. Resource leaks
 
-Format your response as:
-- If no significant issues found: "No critical issues found."
-- If issues found: List each issue with severity (HIGH/MEDIUM/LOW), location, and description
+Respond with a single JSON object and nothing else:
+{
+  "summary": "one or two sentences about the change overall",
+  "findings": [
+    {
+      "severity": "high" | "medium" | "low",
+      "file": "path of the file as shown in the diff",
+      "start_line": first affected line number in the new version of the file,
+      "end_line": last affected line number in the new version of the file,
+      "category": "bug" | "security" | "performance" | "error-handling" | "concurrency" | "resource-leak" | "maintainability",
+      "title": "short title",
+      "explanation": "why this is a problem",
+      "suggested_fix": "how to fix it"
+    }
+  ]
+}
 
+Use an empty "findings" array if there are no significant issues.
 Be concise and actionable. Only report real issues, not style preferences.`
 
 const reviewPromptZH = `你是一位资深软件工程师，正在审查代码变更。
@@ -51,20 +70,205 @@ const reviewPromptZH = `你是一位资深软件工程师，正在审查代码
 5. 竞态条件或并发问题
 6. 资源泄漏
 
-输出格式：
-- 如果没有发现重大问题："未发现严重问题。"
-- 如果发现问题：列出每个问题的严重程度（高/中/低）、位置和描述
+只输出一个 JSON 对象，不要输出其他内容：
+{
+  "summary": "用一两句话概括本次变更",
+  "findings": [
+    {
+      "severity": "high" | "medium" | "low",
+      "file": "diff 中显示的文件路径",
+      "start_line": 问题在新版本文件中的起始行号,
+      "end_line": 问题在新版本文件中的结束行号,
+      "category": "bug" | "security" | "performance" | "error-handling" | "concurrency" | "resource-leak" | "maintainability",
+      "title": "简短标题",
+      "explanation": "问题原因",
+      "suggested_fix": "修复建议"
+    }
+  ]
+}
 
+severity 和 category 必须使用上面列出的英文取值，其余文本使用中文。
+如果没有发现重大问题，"findings" 为空数组。
 请简洁且可操作。只报告真正的问题，而非代码风格偏好。`
 
-func getCommitPrompt(language string) string {
+const summaryPromptEN = `You are summarising one part of a git diff that is too large to process at once.
+Describe what changed in this part and, where it is evident, why.
+
+Rules:
+1. Use a few concise bullet points
+2. Mention the affected files or components
+3. Output ONLY the bullet points, nothing else`
+
+const summaryPromptZH = `你正在总结一个过大而无法一次处理的 git diff 的其中一部分。
+描述这一部分改了什么，以及在能看出的情况下为什么改。
+
+规则：
+1. 使用几个简洁的要点
+2. 提及受影响的文件或模块
+3. 只输出要点，不要输出其他内容
+4. 使用中文描述`
+
+const synthesisNoteEN = `
+
+The input is not a raw diff. It is a set of summaries of the parts of one large change.
+Write a single commit message that covers the whole change.`
+
+const synthesisNoteZH = `
+
+输入不是原始 diff，而是同一个大型变更各部分的摘要。
+请为整个变更生成一条提交信息。`
+
+const splitPromptEN = `You are splitting staged changes into separate, atomic git commits.
+The input lists the numbered hunks of a git diff. Group them into coherent logical changes,
+such as a refactor, a bug fix and a test update, and write a commit message for each group.
+
+Rules:
+1. Every hunk number must appear in exactly one commit
+2. Hunks that depend on each other belong in the same commit
+3. Order the commits so that each one builds on the ones before it
+4. Do not split related changes; a single commit is fine when the change is one piece of work
+
+Write each commit message following these instructions, which describe a single commit:
+
+`
+
+const splitFormatEN = `
+
+Respond with a single JSON object and nothing else, commits in the order they should be made:
+{
+  "commits": [
+    {"message": "the full commit message", "hunks": [1, 2]}
+  ]
+}`
+
+const splitPromptZH = `你正在把暂存的变更拆分为多个独立、原子的 git 提交。
+输入列出了 git diff 中带编号的各个 hunk。请把它们分组为连贯的逻辑变更（例如一次重构、一次 bug 修复和一次测试更新），并为每一组编写提交信息。
+
+规则：
+1. 每个 hunk 编号必须且只能出现在一个提交中
+2. 相互依赖的 hunk 放在同一个提交中
+3. 按顺序排列提交，使每个提交都建立在之前的提交之上
+4. 不要拆开相关的变更；如果变更本身是一件事，只有一个提交也可以
+
+每条提交信息按照以下针对单个提交的说明编写：
+
+`
+
+const splitFormatZH = `
+
+只输出一个 JSON 对象，不要输出其他内容，提交按应创建的顺序排列：
+{
+  "commits": [
+    {"message": "完整的提交信息", "hunks": [1, 2]}
+  ]
+}`
+
+const refinePromptEN = `Revise the commit message according to this instruction: %s
+
+Follow the same rules as before and output ONLY the revised commit message.`
+
+const refinePromptZH = `请按照以下要求修改提交信息：%s
+
+遵循之前的规则，只输出修改后的提交信息。`
+
+// styleText holds the wording of a repository's commit style guide; see
+// CommitStyle.Guide.
+type styleText struct {
+	header, gitmoji, plain, ticket, branchTicket, examples string
+}
+
+var styleTextEN = styleText{
+	header:       "Follow this repository's commit style, which takes precedence over the format rules above:",
+	gitmoji:      "Start the subject with the gitmoji that fits the change (for example ✨ feature, 🐛 fix, 📝 docs, ♻️ refactor, ✅ tests, 🔧 configuration), followed by a short description. Do not use a <type>(<scope>): prefix.",
+	plain:        "Write the subject as a plain imperative sentence starting with a capital letter, such as \"Add ...\" or \"Fix ...\". Do not use a <type>(<scope>): prefix.",
+	ticket:       "Start the subject with the ticket reference, as the examples do.",
+	branchTicket: "The current branch refers to %s.",
+	examples:     "Recent commit messages from this repository, newest first. Match their style, not their content:",
+}
+
+var styleTextZH = styleText{
+	header:       "遵循本仓库的提交风格，它优先于上面的格式规则：",
+	gitmoji:      "主题以符合变更的 gitmoji 开头（例如 ✨ 新功能、🐛 修复、📝 文档、♻️ 重构、✅ 测试、🔧 配置），后接简短描述。不要使用 <type>(<scope>): 前缀。",
+	plain:        "主题写成一句简单的祈使句，不要使用 <type>(<scope>): 前缀。",
+	ticket:       "主题以工单编号开头，与示例保持一致。",
+	branchTicket: "当前分支对应的工单是 %s。",
+	examples:     "以下是本仓库最近的提交信息（从新到旧）。模仿它们的风格，而不是内容：",
+}
+
+// WithCommitContext prefixes a diff with the messages of the commits that
+// produced it, so the reviewer can judge the change against its intent.
+func WithCommitContext(diff string, messages []string) string {
+	if len(messages) == 0 {
+		return diff
+	}
+
+	var b strings.Builder
+	b.WriteString("The diff below was produced by these commits (oldest first):\n\n")
+	for _, msg := range messages {
+		for _, line := range strings.Split(strings.TrimSpace(msg), "\n") {
+			b.WriteString("    ")
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("Diff:\n")
+	b.WriteString(diff)
+	return b.String()
+}
+
+func (p Prompts) commitPrompt(language string) string {
+	if custom, ok := p.custom[PromptCommit]; ok {
+		return custom
+	}
+	prompt := commitPromptEN
+	if language == "zh" {
+		prompt = commitPromptZH
+	}
+	if p.styleGuide != "" {
+		prompt += "\n\n" + p.styleGuide
+	}
+	return prompt
+}
+
+// getRefinePrompt wraps a user's instruction for revising the previous
+// commit message.
+func getRefinePrompt(language, instruction string) string {
+	if language == "zh" {
+		return fmt.Sprintf(refinePromptZH, instruction)
+	}
+	return fmt.Sprintf(refinePromptEN, instruction)
+}
+
+func getSummaryPrompt(language string) string {
 	if language == "zh" {
-		return commitPromptZH
+		return summaryPromptZH
 	}
-	return commitPromptEN
+	return summaryPromptEN
 }
 
-func getReviewPrompt(language string) string {
+// synthesisPrompt is the commit prompt adapted to summaries instead of a
+// raw diff, used for the final step of large-diff summarisation.
+func (p Prompts) synthesisPrompt(language string) string {
+	if language == "zh" {
+		return p.commitPrompt(language) + synthesisNoteZH
+	}
+	return p.commitPrompt(language) + synthesisNoteEN
+}
+
+// splitPrompt asks for a commit plan, with each message written as the
+// commit prompt would write it.
+func (p Prompts) splitPrompt(language string) string {
+	if language == "zh" {
+		return splitPromptZH + p.commitPrompt(language) + splitFormatZH
+	}
+	return splitPromptEN + p.commitPrompt(language) + splitFormatEN
+}
+
+func (p Prompts) reviewPrompt(language string) string {
+	if custom, ok := p.custom[PromptReview]; ok {
+		return custom
+	}
 	if language == "zh" {
 		return reviewPromptZH
 	}
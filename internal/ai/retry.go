@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	maxAttempts = 3
+	baseBackoff = time.Second
+	maxBackoff  = 20 * time.Second
+	// maxRetryAfter caps how long a server may ask us to wait. Longer
+	// waits are not worth sitting through; the request fails instead and
+	// the next fallback, if any, is tried.
+	maxRetryAfter = 30 * time.Second
+)
+
+// retryClient repeats requests that failed with a retryable APIError, with
+// jittered exponential backoff or the server's Retry-After. Streams are only
+// retried while no token has been delivered.
+type retryClient struct {
+	Client
+	completer completer
+}
+
+func newRetryClient(client Client) Client {
+	c, _ := client.(completer)
+	return &retryClient{Client: client, completer: c}
+}
+
+func (r *retryClient) GenerateCommitMessage(ctx context.Context, diff, language string) (string, error) {
+	return withRetry(ctx, nil, func() (string, error) {
+		return r.Client.GenerateCommitMessage(ctx, diff, language)
+	})
+}
+
+func (r *retryClient) RefineCommitMessage(ctx context.Context, diff, language string, history []Message, instruction string) (string, error) {
+	return withRetry(ctx, nil, func() (string, error) {
+		return r.Client.RefineCommitMessage(ctx, diff, language, history, instruction)
+	})
+}
+
+func (r *retryClient) ReviewCode(ctx context.Context, diff, language string) (*ReviewResult, error) {
+	return withRetry(ctx, nil, func() (*ReviewResult, error) {
+		return r.Client.ReviewCode(ctx, diff, language)
+	})
+}
+
+func (r *retryClient) StreamCommitMessage(ctx context.Context, diff, language string, onToken TokenFunc) (string, error) {
+	s, ok := r.Client.(Streamer)
+	if !ok {
+		message, err := r.GenerateCommitMessage(ctx, diff, language)
+		if err == nil {
+			onToken(message)
+		}
+		return message, err
+	}
+	started := false
+	onStarted := func(token string) {
+		started = true
+		onToken(token)
+	}
+	return withRetry(ctx, &started, func() (string, error) {
+		return s.StreamCommitMessage(ctx, diff, language, onStarted)
+	})
+}
+
+func (r *retryClient) StreamReviewCode(ctx context.Context, diff, language string, onToken TokenFunc) (*ReviewResult, error) {
+	s, ok := r.Client.(Streamer)
+	if !ok {
+		return r.ReviewCode(ctx, diff, language)
+	}
+	started := false
+	onStarted := func(token string) {
+		started = true
+		onToken(token)
+	}
+	return withRetry(ctx, &started, func() (*ReviewResult, error) {
+		return s.StreamReviewCode(ctx, diff, language, onStarted)
+	})
+}
+
+// GenerateCommitMessages uses the provider's native support when it has
+// it. Some OpenAI-compatible servers reject or ignore the request for several
+// answers; the difference is made up with parallel single requests.
+func (r *retryClient) GenerateCommitMessages(ctx context.Context, diff, language string, n int) ([]string, error) {
+	var messages []string
+	if g, ok := r.Client.(CandidateGenerator); ok {
+		m, err := withRetry(ctx, nil, func() ([]string, error) {
+			return g.GenerateCommitMessages(ctx, diff, language, n)
+		})
+		var apiErr *APIError
+		if err != nil && !(errors.As(err, &apiErr) && apiErr.Kind == ErrBadRequest) {
+			return nil, err
+		}
+		messages = m
+	}
+
+	if missing := n - len(messages); missing > 0 {
+		more, err := parallelCandidates(ctx, missing, func(ctx context.Context) (string, error) {
+			return r.GenerateCommitMessage(ctx, diff, language)
+		})
+		if err != nil && len(messages) == 0 {
+			return nil, err
+		}
+		messages = append(messages, more...)
+	}
+	return messages, nil
+}
+
+func (r *retryClient) call(ctx context.Context, systemPrompt string, messages []Message, schema map[string]any) (string, error) {
+	if r.completer == nil {
+		return "", errors.New("provider does not support raw requests")
+	}
+	return withRetry(ctx, nil, func() (string, error) {
+		return r.completer.call(ctx, systemPrompt, messages, schema)
+	})
+}
+
+// withRetry runs fn until it succeeds, fails with an error that is not
+// retryable, or runs out of attempts. When started is set and becomes true,
+// output has already been shown and the request is not repeated.
+func withRetry[T any](ctx context.Context, started *bool, fn func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil || !IsRetryable(err) || attempt == maxAttempts || (started != nil && *started) {
+			return v, err
+		}
+
+		wait, ok := retryDelay(err, attempt)
+		if !ok {
+			return v, err
+		}
+		logf("⚠ %v; retrying in %.1fs (attempt %d/%d)\n", err, wait.Seconds(), attempt+1, maxAttempts)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return v, err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay returns how long to wait before the next attempt. A server
+// supplied Retry-After is honoured up to maxRetryAfter; otherwise the delay
+// doubles per attempt with jitter so concurrent requests spread out.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > maxRetryAfter {
+			return 0, false
+		}
+		return apiErr.RetryAfter, true
+	}
+
+	d := min(baseBackoff<<(attempt-1), maxBackoff)
+	return d/2 + rand.N(d/2+1), true
+}
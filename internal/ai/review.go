@@ -0,0 +1,236 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityHigh   Severity = "high"
+	SeverityMedium Severity = "medium"
+	SeverityLow    Severity = "low"
+)
+
+// Rank orders severities so thresholds can be compared; unknown values rank 0.
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func ParseSeverity(s string) (Severity, error) {
+	if sev := normalizeSeverity(s); sev != "" {
+		return sev, nil
+	}
+	return "", fmt.Errorf("invalid severity: %s (use: high, medium, low)", s)
+}
+
+func normalizeSeverity(s string) Severity {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "high", "critical", "blocker", "高":
+		return SeverityHigh
+	case "medium", "moderate", "warning", "中":
+		return SeverityMedium
+	case "low", "minor", "info", "低":
+		return SeverityLow
+	default:
+		return ""
+	}
+}
+
+type Finding struct {
+	Severity     Severity `json:"severity"`
+	File         string   `json:"file"`
+	StartLine    int      `json:"start_line"`
+	EndLine      int      `json:"end_line"`
+	Category     string   `json:"category"`
+	Title        string   `json:"title"`
+	Explanation  string   `json:"explanation"`
+	SuggestedFix string   `json:"suggested_fix"`
+}
+
+type ReviewResult struct {
+	Summary  string    `json:"summary"`
+	Findings []Finding `json:"findings"`
+}
+
+// CountAtOrAbove returns how many findings are at least as severe as threshold.
+func (r *ReviewResult) CountAtOrAbove(threshold Severity) int {
+	count := 0
+	for _, f := range r.Findings {
+		if f.Severity.Rank() >= threshold.Rank() {
+			count++
+		}
+	}
+	return count
+}
+
+// reviewSchema is the JSON schema every provider is asked to follow for
+// reviews. It is written in the strict subset accepted by OpenAI structured
+// outputs; providers with a different dialect convert it.
+var reviewSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"summary": map[string]any{"type": "string"},
+		"findings": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"severity":      map[string]any{"type": "string", "enum": []string{"high", "medium", "low"}},
+					"file":          map[string]any{"type": "string"},
+					"start_line":    map[string]any{"type": "integer"},
+					"end_line":      map[string]any{"type": "integer"},
+					"category":      map[string]any{"type": "string", "enum": reviewCategories},
+					"title":         map[string]any{"type": "string"},
+					"explanation":   map[string]any{"type": "string"},
+					"suggested_fix": map[string]any{"type": "string"},
+				},
+				"required":             []string{"severity", "file", "start_line", "end_line", "category", "title", "explanation", "suggested_fix"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"summary", "findings"},
+	"additionalProperties": false,
+}
+
+var reviewCategories = []string{"bug", "security", "performance", "error-handling", "concurrency", "resource-leak", "maintainability"}
+
+// ParseReview turns a model answer into a ReviewResult. It accepts the
+// schema'd JSON object (optionally wrapped in a code fence or prose), a bare
+// array of findings, and as a last resort the free-text "SEVERITY: ..."
+// layout older prompts produced.
+func ParseReview(text string) (*ReviewResult, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("empty review response")
+	}
+
+	if result, ok := parseReviewJSON(text); ok {
+		return result, nil
+	}
+
+	// Prose without any labelled finding cannot be told apart from a
+	// response that ignored the format, so it must not pass as "no issues".
+	result := parseReviewText(text)
+	if len(result.Findings) == 0 {
+		return nil, fmt.Errorf("review response is neither JSON nor labelled findings")
+	}
+	return result, nil
+}
+
+func parseReviewJSON(text string) (*ReviewResult, bool) {
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		var result ReviewResult
+		if err := json.Unmarshal([]byte(text[start:end+1]), &result); err == nil && (result.Findings != nil || result.Summary != "") {
+			normalizeFindings(result.Findings)
+			return &result, true
+		}
+	}
+
+	if start, end := strings.Index(text, "["), strings.LastIndex(text, "]"); start >= 0 && end > start {
+		var findings []Finding
+		if err := json.Unmarshal([]byte(text[start:end+1]), &findings); err == nil {
+			normalizeFindings(findings)
+			return &ReviewResult{Findings: findings}, true
+		}
+	}
+
+	return nil, false
+}
+
+func normalizeFindings(findings []Finding) {
+	for i := range findings {
+		f := &findings[i]
+		if sev := normalizeSeverity(string(f.Severity)); sev != "" {
+			f.Severity = sev
+		} else {
+			f.Severity = SeverityLow
+		}
+		if f.EndLine < f.StartLine {
+			f.EndLine = f.StartLine
+		}
+		f.Category = strings.ToLower(strings.TrimSpace(f.Category))
+	}
+}
+
+var (
+	severityLabelRe = regexp.MustCompile(`(?i)^[\s\-*#>\d.]*(?:severity\s*|严重程度\s*)?[\[(（【*:：]*\s*(high|medium|low|critical|高|中|低)(?:\s*严重性)?\s*[\])）】*]*\s*[:：\-–]\s*(.*)$`)
+	locationRe      = regexp.MustCompile("`?([\\w./\\-]+\\.[A-Za-z0-9]+):(\\d+)(?:-(\\d+))?`?")
+)
+
+func parseReviewText(text string) *ReviewResult {
+	result := &ReviewResult{}
+	var current *Finding
+	var summary []string
+
+	flush := func() {
+		if current != nil {
+			current.Explanation = strings.TrimSpace(current.Explanation)
+			result.Findings = append(result.Findings, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if m := severityLabelRe.FindStringSubmatch(line); m != nil {
+			flush()
+			current = &Finding{
+				Severity: normalizeSeverity(m[1]),
+				Title:    strings.Trim(strings.TrimSpace(m[2]), "*"),
+			}
+			if loc := locationRe.FindStringSubmatch(m[2]); loc != nil {
+				current.File = loc[1]
+				current.StartLine, _ = strconv.Atoi(loc[2])
+				current.EndLine = current.StartLine
+				if loc[3] != "" {
+					current.EndLine, _ = strconv.Atoi(loc[3])
+				}
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if current == nil {
+			if trimmed != "" {
+				summary = append(summary, trimmed)
+			}
+			continue
+		}
+
+		item := strings.TrimLeft(trimmed, "-* ")
+		if fix, ok := cutFixLabel(item); ok {
+			current.SuggestedFix = fix
+			continue
+		}
+		if item != "" {
+			current.Explanation += item + "\n"
+		}
+	}
+	flush()
+
+	result.Summary = strings.Join(summary, " ")
+	return result
+}
+
+func cutFixLabel(item string) (string, bool) {
+	for _, label := range []string{"Fix:", "fix:", "Suggestion:", "修复：", "建议：", "修复:", "建议:"} {
+		if rest, ok := strings.CutPrefix(item, label); ok {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SplitPlan is a proposed sequence of commits for one set of staged changes.
+type SplitPlan struct {
+	Commits []PlannedCommit `json:"commits"`
+}
+
+// PlannedCommit is one commit of a SplitPlan. Hunks are the 1-based numbers
+// of the hunks it contains, as listed in the input.
+type PlannedCommit struct {
+	Message string `json:"message"`
+	Hunks   []int  `json:"hunks"`
+}
+
+// Splitter is implemented by clients that can group hunks into commits.
+type Splitter interface {
+	PlanSplit(ctx context.Context, hunks, language string) (*SplitPlan, error)
+}
+
+var (
+	_ Splitter = (*chunkingClient)(nil)
+	_ Splitter = (*fallbackClient)(nil)
+)
+
+var splitSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"commits": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"message": map[string]any{"type": "string"},
+					"hunks":   map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+				},
+				"required":             []string{"message", "hunks"},
+				"additionalProperties": false,
+			},
+		},
+	},
+	"required":             []string{"commits"},
+	"additionalProperties": false,
+}
+
+// PlanSplit asks the model to group count numbered hunks into commits. The
+// plan is normalised so that every hunk is in exactly one commit: unknown and
+// repeated numbers are dropped and hunks the model left out are added to the
+// last commit.
+func PlanSplit(ctx context.Context, client Client, hunks, language string, count int) (*SplitPlan, error) {
+	s, ok := client.(Splitter)
+	if !ok {
+		return nil, errors.New("provider does not support splitting commits")
+	}
+	plan, err := s.PlanSplit(ctx, hunks, language)
+	if err != nil {
+		return nil, err
+	}
+	return normalizePlan(plan, count)
+}
+
+// parseSplitPlan extracts a SplitPlan from a model answer, which may wrap
+// the JSON object in a code fence or prose.
+func parseSplitPlan(text string) (*SplitPlan, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no commit plan in response")
+	}
+	var plan SplitPlan
+	if err := json.Unmarshal([]byte(text[start:end+1]), &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse commit plan: %w", err)
+	}
+	return &plan, nil
+}
+
+func normalizePlan(plan *SplitPlan, count int) (*SplitPlan, error) {
+	seen := make(map[int]bool)
+	var commits []PlannedCommit
+	for _, c := range plan.Commits {
+		message := strings.TrimSpace(c.Message)
+		if message == "" {
+			continue
+		}
+		var hunks []int
+		for _, h := range c.Hunks {
+			if h >= 1 && h <= count && !seen[h] {
+				seen[h] = true
+				hunks = append(hunks, h)
+			}
+		}
+		if len(hunks) == 0 {
+			continue
+		}
+		slices.Sort(hunks)
+		commits = append(commits, PlannedCommit{Message: message, Hunks: hunks})
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no usable commits in plan")
+	}
+
+	last := &commits[len(commits)-1]
+	for h := 1; h <= count; h++ {
+		if !seen[h] {
+			last.Hunks = append(last.Hunks, h)
+		}
+	}
+	slices.Sort(last.Hunks)
+	return &SplitPlan{Commits: commits}, nil
+}
+
+// PlanSplit plans in one request; unlike commit messages, a plan cannot be
+// assembled from summaries because it must see every hunk.
+func (c *chunkingClient) PlanSplit(ctx context.Context, hunks, language string) (*SplitPlan, error) {
+	prompt := c.prompts.splitPrompt(language)
+	budget := InputBudget(c.model, prompt, c.maxTokensIn)
+	if n := EstimateTokens(c.model, hunks); n > budget {
+		return nil, fmt.Errorf("staged changes are too large to split with %s (~%d tokens, budget %d); stage fewer changes", c.model, n, budget)
+	}
+	text, err := c.completer.call(ctx, prompt, userTurn(hunks), splitSchema)
+	if err != nil {
+		return nil, err
+	}
+	return parseSplitPlan(text)
+}
+
+func (f *fallbackClient) PlanSplit(ctx context.Context, hunks, language string) (*SplitPlan, error) {
+	var plan *SplitPlan
+	err := f.run(func(c Client) error {
+		s, ok := c.(Splitter)
+		if !ok {
+			return errors.New("provider does not support splitting commits")
+		}
+		var err error
+		plan, err = s.PlanSplit(ctx, hunks, language)
+		return err
+	}, nil)
+	return plan, err
+}
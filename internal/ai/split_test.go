@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizePlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		plan    []PlannedCommit
+		count   int
+		want    []PlannedCommit
+		wantErr bool
+	}{
+		{
+			name:  "complete plan is kept",
+			plan:  []PlannedCommit{{Message: "feat: a", Hunks: []int{1, 2}}, {Message: "fix: b", Hunks: []int{3}}},
+			count: 3,
+			want:  []PlannedCommit{{Message: "feat: a", Hunks: []int{1, 2}}, {Message: "fix: b", Hunks: []int{3}}},
+		},
+		{
+			name:  "hunks are sorted and messages trimmed",
+			plan:  []PlannedCommit{{Message: "  feat: a\n", Hunks: []int{3, 1, 2}}},
+			count: 3,
+			want:  []PlannedCommit{{Message: "feat: a", Hunks: []int{1, 2, 3}}},
+		},
+		{
+			name:  "a hunk planned twice stays in the first commit",
+			plan:  []PlannedCommit{{Message: "feat: a", Hunks: []int{1, 2}}, {Message: "fix: b", Hunks: []int{2, 3}}},
+			count: 3,
+			want:  []PlannedCommit{{Message: "feat: a", Hunks: []int{1, 2}}, {Message: "fix: b", Hunks: []int{3}}},
+		},
+		{
+			name:  "out of range hunks are dropped",
+			plan:  []PlannedCommit{{Message: "feat: a", Hunks: []int{0, 1, 2, 9}}},
+			count: 2,
+			want:  []PlannedCommit{{Message: "feat: a", Hunks: []int{1, 2}}},
+		},
+		{
+			name:  "unplanned hunks go to the last commit",
+			plan:  []PlannedCommit{{Message: "feat: a", Hunks: []int{2}}, {Message: "fix: b", Hunks: []int{4}}},
+			count: 5,
+			want:  []PlannedCommit{{Message: "feat: a", Hunks: []int{2}}, {Message: "fix: b", Hunks: []int{1, 3, 4, 5}}},
+		},
+		{
+			name: "commits without a message or hunks are dropped",
+			plan: []PlannedCommit{
+				{Message: " ", Hunks: []int{1}},
+				{Message: "feat: a", Hunks: []int{2}},
+				{Message: "fix: b", Hunks: []int{2, 7}},
+			},
+			count: 2,
+			want:  []PlannedCommit{{Message: "feat: a", Hunks: []int{1, 2}}},
+		},
+		{
+			name:    "no usable commit",
+			plan:    []PlannedCommit{{Message: "feat: a"}, {Message: "", Hunks: []int{1}}},
+			count:   1,
+			wantErr: true,
+		},
+		{
+			name:    "empty plan",
+			count:   2,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizePlan(&SplitPlan{Commits: tt.plan}, tt.count)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("normalizePlan() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizePlan() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Commits, tt.want) {
+				t.Errorf("normalizePlan() = %+v, want %+v", got.Commits, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/git"
+)
+
+const (
+	// StyleSampleSize is how many recent commits are examined.
+	StyleSampleSize = 50
+	// minStyleSample is the fewest commits from which a style is inferred.
+	minStyleSample    = 5
+	maxStyleExamples  = 5
+	maxExampleBodyLen = 6 // lines
+)
+
+var (
+	conventionalRe = regexp.MustCompile(`^[a-zA-Z]+(\([^)]*\))?!?: \S`)
+	gitmojiCodeRe  = regexp.MustCompile(`^:[a-z0-9_+-]+:`)
+	ticketPrefixRe = regexp.MustCompile(`^\[?(([A-Z][A-Z0-9]+)-\d+|#\d+)\]?:?\s+`)
+	branchTicketRe = regexp.MustCompile(`(?i)\b([a-z][a-z0-9]+)-\d+\b`)
+	skipCommitRe   = regexp.MustCompile(`^(Merge |Revert "|fixup! |squash! |amend! )`)
+)
+
+// CommitStyle is the commit message convention to ask the model for.
+type CommitStyle struct {
+	Convention string   // config.StyleConventional, StyleGitmoji or StylePlain
+	Ticket     bool     // subjects start with a ticket reference such as ABC-123
+	Language   string   // "zh" when most subjects are Chinese, otherwise "en"
+	Examples   []string // recent messages in this style, newest first
+
+	// projects are the ticket prefixes seen in the history, such as "ABC".
+	projects map[string]bool
+}
+
+type styledCommit struct {
+	message    string
+	convention string
+	ticket     bool
+	project    string
+	chinese    bool
+	kind       string // type or emoji, used to vary the examples
+}
+
+// ResolveStyle returns the style for commits in a repository with the given
+// history, newest first. With style "auto" (or empty) the dominant
+// convention of the history is used, and nil is returned when there are too
+// few commits to tell; otherwise the configured convention is used with any
+// matching commits as examples.
+func ResolveStyle(style string, commits []git.CommitInfo) *CommitStyle {
+	var sample []styledCommit
+	for _, c := range commits {
+		if skipCommitRe.MatchString(c.Subject) {
+			continue
+		}
+		sample = append(sample, classifyCommit(c))
+	}
+
+	if style == "" || style == config.StyleAuto {
+		if len(sample) < minStyleSample {
+			return nil
+		}
+		style = dominantConvention(sample)
+	}
+
+	result := &CommitStyle{Convention: style, Language: "en", projects: map[string]bool{}}
+	var matching []styledCommit
+	tickets, chinese := 0, 0
+	for _, c := range sample {
+		if c.chinese {
+			chinese++
+		}
+		if c.convention == style {
+			matching = append(matching, c)
+			if c.ticket {
+				tickets++
+			}
+			if c.project != "" {
+				result.projects[c.project] = true
+			}
+		}
+	}
+	result.Ticket = len(matching) > 0 && tickets*2 > len(matching)
+	if chinese*2 > len(sample) {
+		result.Language = "zh"
+	}
+	result.Examples = pickExamples(matching, result.Ticket)
+	return result
+}
+
+func classifyCommit(c git.CommitInfo) styledCommit {
+	subject := c.Subject
+	sc := styledCommit{message: c.Subject, convention: config.StylePlain}
+	if c.Body != "" {
+		lines := strings.Split(c.Body, "\n")
+		if len(lines) > maxExampleBodyLen {
+			lines = append(lines[:maxExampleBodyLen], "...")
+		}
+		sc.message += "\n\n" + strings.Join(lines, "\n")
+	}
+
+	if m := ticketPrefixRe.FindStringSubmatch(subject); m != nil {
+		sc.ticket = true
+		sc.project = m[2]
+		subject = subject[len(m[0]):]
+	}
+	for _, r := range subject {
+		if unicode.Is(unicode.Han, r) {
+			sc.chinese = true
+			break
+		}
+	}
+
+	switch {
+	case conventionalRe.MatchString(subject):
+		sc.convention = config.StyleConventional
+		sc.kind = strings.ToLower(subject[:strings.IndexAny(subject, "(!:")])
+	case gitmojiCodeRe.MatchString(subject):
+		sc.convention = config.StyleGitmoji
+		sc.kind = gitmojiCodeRe.FindString(subject)
+	case startsWithEmoji(subject):
+		sc.convention = config.StyleGitmoji
+		sc.kind = strings.Fields(subject)[0]
+	}
+	return sc
+}
+
+func startsWithEmoji(s string) bool {
+	for _, r := range s {
+		return unicode.Is(unicode.So, r) || (r >= 0x1F000 && r <= 0x1FAFF)
+	}
+	return false
+}
+
+// dominantConvention returns the most common convention, preferring
+// conventional commits and then gitmoji on ties.
+func dominantConvention(sample []styledCommit) string {
+	counts := map[string]int{}
+	for _, c := range sample {
+		counts[c.convention]++
+	}
+	best := config.StyleConventional
+	for _, style := range []string{config.StyleGitmoji, config.StylePlain} {
+		if counts[style] > counts[best] {
+			best = style
+		}
+	}
+	return best
+}
+
+// pickExamples chooses up to maxStyleExamples messages, newest first,
+// preferring different types or emoji so the model sees the range in use.
+func pickExamples(commits []styledCommit, ticket bool) []string {
+	var picked []string
+	used := map[int]bool{}
+	seenKind := map[string]bool{}
+	for pass := 0; pass < 2; pass++ {
+		for i, c := range commits {
+			if len(picked) == maxStyleExamples {
+				return picked
+			}
+			if used[i] || c.ticket != ticket || (pass == 0 && seenKind[c.kind]) {
+				continue
+			}
+			used[i] = true
+			seenKind[c.kind] = true
+			picked = append(picked, c.message)
+		}
+	}
+	return picked
+}
+
+// Guide returns the style instructions and examples added to the commit
+// prompt, written in language. branch is the current branch, whose ticket
+// reference is suggested when the style uses tickets from the same project.
+func (s *CommitStyle) Guide(language, branch string) string {
+	text := styleTextEN
+	if language == "zh" {
+		text = styleTextZH
+	}
+
+	var rules []string
+	switch s.Convention {
+	case config.StyleGitmoji:
+		rules = append(rules, text.gitmoji)
+	case config.StylePlain:
+		rules = append(rules, text.plain)
+	}
+	if s.Ticket {
+		rule := text.ticket
+		for _, m := range branchTicketRe.FindAllStringSubmatch(branch, -1) {
+			if s.projects[strings.ToUpper(m[1])] {
+				rule += " " + fmt.Sprintf(text.branchTicket, strings.ToUpper(m[0]))
+				break
+			}
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 && len(s.Examples) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(text.header)
+	for _, r := range rules {
+		b.WriteString("\n- " + r)
+	}
+	if len(s.Examples) > 0 {
+		b.WriteString("\n\n" + text.examples + "\n")
+		for _, e := range s.Examples {
+			b.WriteString("\n---\n" + e)
+		}
+		b.WriteString("\n---")
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/go-goll/aigit/internal/config"
+	"github.com/go-goll/aigit/internal/git"
+)
+
+// Prompt template names. Each can be replaced by <name>.tmpl in the
+// repository's .aigit/prompts/ or in ~/.aigit/prompts/.
+const (
+	PromptCommit = "commit"
+	PromptReview = "review"
+)
+
+var PromptNames = []string{PromptCommit, PromptReview}
+
+// PromptData holds the variables available to prompt templates.
+type PromptData struct {
+	Language      string   // output language, "en" or "zh"
+	Repo          string   // name of the repository's top-level directory
+	Branch        string   // current branch, empty on a detached HEAD
+	Files         []string // files in the diff being described or reviewed
+	RecentCommits []string // subjects of recent commits, newest first
+	StyleGuide    string   // the repository's commit style and examples, for commit prompts
+}
+
+// Prompts holds the system prompts for one command, as rendered by
+// LoadPrompts and passed to NewClient. The zero value selects the built-in
+// prompts without a style guide.
+type Prompts struct {
+	custom     map[string]string // rendered user templates by name
+	styleGuide string            // added to the built-in commit prompt
+}
+
+var promptFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// BuiltinPrompt returns the source of the built-in template for name, with
+// a header describing the variables, as written by `aigit prompts edit`.
+func BuiltinPrompt(name string) (string, error) {
+	var en, zh, note, extra string
+	switch name {
+	case PromptCommit:
+		en, zh = commitPromptEN, commitPromptZH
+		extra = "{{with .StyleGuide}}\n\n{{.}}{{end}}"
+	case PromptReview:
+		en, zh = reviewPromptEN, reviewPromptZH
+		note = "\n  Keep the JSON response format: aigit parses the answer as shown below."
+	default:
+		return "", fmt.Errorf("unknown prompt: %s (use: %s)", name, strings.Join(PromptNames, ", "))
+	}
+	return fmt.Sprintf(`{{- /*
+  aigit %s prompt. Variables: .Language .Repo .Branch .Files .RecentCommits .StyleGuide
+  (lists can be joined with {{join .Files ", "}}).%s
+  Run 'aigit prompts reset %s' to restore the built-in prompt.
+*/ -}}
+{{if eq .Language "zh"}}%s{{else}}%s{{end}}%s
+`, name, note, name, zh, en, extra), nil
+}
+
+// PromptPaths returns the template files for name that may override the
+// built-in prompt, in order of precedence: the repository's, then the
+// user's. Either is empty when it cannot be determined.
+func PromptPaths(name string) (repo, user string) {
+	if root, err := git.RepoRoot(); err == nil {
+		repo = filepath.Join(root, ".aigit", "prompts", name+".tmpl")
+	}
+	if dir, err := config.Dir(); err == nil {
+		user = filepath.Join(dir, "prompts", name+".tmpl")
+	}
+	return repo, user
+}
+
+// FindPrompt returns the template source in effect for name and the file
+// it came from, or "" for the built-in.
+func FindPrompt(name string) (source, path string, err error) {
+	repo, user := PromptPaths(name)
+	for _, p := range []string{repo, user} {
+		if p == "" {
+			continue
+		}
+		data, err := os.ReadFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read prompt: %w", err)
+		}
+		return string(data), p, nil
+	}
+	source, err = BuiltinPrompt(name)
+	return source, "", err
+}
+
+// CheckPrompt reports whether source parses and renders with sample data,
+// catching misspelt variables before the template is used.
+func CheckPrompt(name, source string) error {
+	tmpl, err := parsePrompt(name, source)
+	if err != nil {
+		return err
+	}
+	sample := PromptData{
+		Language:      "en",
+		Repo:          "repo",
+		Branch:        "main",
+		Files:         []string{"main.go"},
+		RecentCommits: []string{"Initial commit"},
+	}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return fmt.Errorf("invalid %s prompt: %w", name, err)
+	}
+	return nil
+}
+
+func parsePrompt(name, source string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s prompt: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// LoadPrompts renders the user's prompt templates, if any, with data. The
+// built-in prompts are used for names without a template file.
+func LoadPrompts(data PromptData) (Prompts, error) {
+	prompts := Prompts{custom: map[string]string{}, styleGuide: data.StyleGuide}
+	for _, name := range PromptNames {
+		source, path, err := FindPrompt(name)
+		if err != nil {
+			return Prompts{}, err
+		}
+		if path == "" {
+			continue
+		}
+
+		tmpl, err := parsePrompt(name, source)
+		if err != nil {
+			return Prompts{}, fmt.Errorf("%s: %w", path, err)
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return Prompts{}, fmt.Errorf("failed to render %s: %w", path, err)
+		}
+		prompts.custom[name] = strings.TrimSpace(b.String())
+	}
+	return prompts, nil
+}
@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// outputReserve is the part of the context window kept free for the answer.
+const outputReserve = 4096
+
+// defaultContextWindow is assumed for models missing from contextWindows. It
+// is deliberately small: overestimating leads to provider errors, while
+// underestimating only costs an extra summarisation round.
+const defaultContextWindow = 16384
+
+// contextWindows maps model name prefixes to their context window in tokens.
+// Longer prefixes are listed before shorter ones that they extend.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"gpt-5", 400000},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini-1.5-flash", 1048576},
+	{"gemini-2", 1048576},
+	{"gemini", 32768},
+	{"deepseek", 65536},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3", 8192},
+	{"qwen2.5", 32768},
+	{"qwen3", 40960},
+	{"mistral", 32768},
+	{"codellama", 16384},
+}
+
+// ContextWindow returns the context window of model in tokens. OpenRouter
+// style "vendor/model" names are matched on the model part.
+func ContextWindow(model string) int {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, w := range contextWindows {
+		if strings.HasPrefix(name, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// charsPerToken is the average number of non-CJK characters per token for
+// the tokenizer family of model, measured on source code and diffs.
+func charsPerToken(model string) float64 {
+	name := strings.ToLower(model)
+	switch {
+	case strings.Contains(name, "gpt-4o"), strings.Contains(name, "gpt-4.1"), strings.Contains(name, "gpt-5"),
+		strings.HasPrefix(name, "o1"), strings.HasPrefix(name, "o3"), strings.HasPrefix(name, "o4"):
+		return 3.8
+	case strings.Contains(name, "gemini"):
+		return 3.8
+	case strings.Contains(name, "gpt"):
+		return 3.6
+	default:
+		return 3.3
+	}
+}
+
+// EstimateTokens approximates how many tokens text takes for model. CJK
+// characters are counted as one token each; everything else by the
+// tokenizer's average character density. The estimate errs on the high side.
+func EstimateTokens(model, text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + int(math.Ceil(float64(other)/charsPerToken(model)))
+}
+
+// InputBudget returns how many tokens of user input can be sent to model
+// alongside systemPrompt. A positive override replaces the computed value.
+func InputBudget(model, systemPrompt string, override int) int {
+	if override > 0 {
+		return override
+	}
+	budget := ContextWindow(model) - outputReserve - EstimateTokens(model, systemPrompt)
+	// Keep a margin for estimation error and message framing.
+	budget = budget * 9 / 10
+	return max(budget, 1024)
+}
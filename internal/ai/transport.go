@@ -0,0 +1,105 @@
+package ai
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-goll/aigit/internal/config"
+)
+
+// Connection timeouts. The overall deadline of a request comes from the
+// command's context (see Config.TimeoutOr) rather than http.Client.Timeout,
+// which would also cut off long-running streams.
+const (
+	dialTimeout           = 30 * time.Second
+	tlsHandshakeTimeout   = 15 * time.Second
+	responseHeaderTimeout = 90 * time.Second
+)
+
+var insecureWarning sync.Once
+
+// NewHTTPClient builds the HTTP client every provider uses, honouring the
+// proxy, ca_file, insecure_skip_verify and headers settings. Without a proxy
+// setting the standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables apply.
+func NewHTTPClient(cfg *config.Config) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	transport.ResponseHeaderTimeout = responseHeaderTimeout
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CAFile != "" || cfg.InsecureSkipVerify {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if cfg.CAFile != "" {
+			pool, err := loadCAPool(cfg.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.InsecureSkipVerify {
+			insecureWarning.Do(func() {
+				logf("⚠ TLS certificate verification is disabled (insecure_skip_verify)\n")
+			})
+			tlsConfig.InsecureSkipVerify = true
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	var rt http.RoundTripper = transport
+	if len(cfg.Headers) > 0 {
+		rt = &headerTransport{base: transport, headers: cfg.Headers}
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+// loadCAPool returns the system roots plus the PEM certificates in path, so
+// a corporate CA can be trusted without losing the public ones.
+func loadCAPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca_file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("failed to load ca_file %s: no PEM certificates found", path)
+	}
+	return pool, nil
+}
+
+// headerTransport adds the configured headers to every request, without
+// overriding those a provider sets itself.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
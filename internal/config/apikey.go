@@ -0,0 +1,160 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-goll/aigit/internal/keyring"
+)
+
+// Values for KeyStore: where 'aigit config api_key' saves keys.
+const (
+	KeyStoreFile    = "file"    // plaintext in config.json
+	KeyStoreKeyring = "keyring" // OS keyring, one entry per provider
+)
+
+// apiKeyCmdTimeout bounds api_key_cmd, which may prompt for a passphrase
+// through a GUI agent.
+const apiKeyCmdTimeout = 60 * time.Second
+
+// HasAPIKeySource reports whether any way of obtaining an API key is
+// configured, without resolving it.
+func (c *Config) HasAPIKeySource() bool {
+	return c.APIKey != "" || c.APIKeyEnv != "" || c.APIKeyCmd != "" || c.KeyStore == KeyStoreKeyring
+}
+
+// DescribeAPIKey tells where the API key will come from without resolving
+// it; a plaintext key is masked.
+func (c *Config) DescribeAPIKey() string {
+	switch {
+	case c.APIKey != "":
+		return maskKey(c.APIKey)
+	case c.APIKeyEnv != "":
+		return "$" + c.APIKeyEnv
+	case c.APIKeyCmd != "":
+		return "$(" + c.APIKeyCmd + ")"
+	case c.KeyStore == KeyStoreKeyring:
+		return "keyring (" + string(c.Provider) + ")"
+	default:
+		return ""
+	}
+}
+
+// APIKeyOrigin returns the layer that configured the key source reported
+// by DescribeAPIKey.
+func (c *Config) APIKeyOrigin() string {
+	switch {
+	case c.APIKey != "":
+		return c.Origin("api_key")
+	case c.APIKeyEnv != "":
+		return c.Origin("api_key_env")
+	case c.APIKeyCmd != "":
+		return c.Origin("api_key_cmd")
+	default:
+		return c.Origin("key_store")
+	}
+}
+
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:4] + "****" + key[len(key)-4:]
+}
+
+// ResolveAPIKey returns the API key for the configured provider. Sources
+// are tried in order: api_key, api_key_env, api_key_cmd, then the keyring
+// when key_store is "keyring". It is called only when a client is created,
+// so commands and keyrings of unused profiles are never touched.
+func (c *Config) ResolveAPIKey() (string, error) {
+	switch {
+	case c.APIKey != "":
+		return c.APIKey, nil
+	case c.APIKeyEnv != "":
+		key := strings.TrimSpace(os.Getenv(c.APIKeyEnv))
+		if key == "" {
+			return "", fmt.Errorf("environment variable %s (api_key_env) is not set", c.APIKeyEnv)
+		}
+		return key, nil
+	case c.APIKeyCmd != "":
+		return runKeyCommand(c.APIKeyCmd)
+	case c.KeyStore == KeyStoreKeyring:
+		store, err := keyring.Default()
+		if err != nil {
+			return "", err
+		}
+		key, err := store.Get(string(c.Provider))
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", fmt.Errorf("no API key for %s in the keyring, run 'aigit config api_key <key>'", c.Provider)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key from keyring: %w", err)
+		}
+		return key, nil
+	}
+
+	if !RequiresAPIKey(c.Provider) {
+		return "", nil
+	}
+	if c.profile != "" {
+		return "", fmt.Errorf("api_key is required for profile %s", c.profile)
+	}
+	return "", errors.New("api_key is required")
+}
+
+func runKeyCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiKeyCmdTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = os.Stdin
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("api_key_cmd failed: %s", msg)
+		}
+		return "", fmt.Errorf("api_key_cmd failed: %w", err)
+	}
+
+	// Tools like 'pass' print the secret on the first line and metadata
+	// after it.
+	key, _, _ := strings.Cut(strings.TrimSpace(out.String()), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("api_key_cmd printed no key")
+	}
+	return key, nil
+}
+
+// StoreAPIKey saves key for provider in the configured key store. With the
+// file store it is kept in c and written by Save; with the keyring it goes
+// to the OS keyring and any plaintext copy is removed from c.
+func (c *Config) StoreAPIKey(provider Provider, key string) error {
+	if c.KeyStore != KeyStoreKeyring {
+		c.APIKey = key
+		return nil
+	}
+	store, err := keyring.Default()
+	if err != nil {
+		return err
+	}
+	if err := store.Set(string(provider), key); err != nil {
+		return fmt.Errorf("failed to store API key in keyring: %w", err)
+	}
+	c.APIKey = ""
+	return nil
+}
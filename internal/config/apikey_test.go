@@ -0,0 +1,138 @@
+package config
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-goll/aigit/internal/keyring"
+)
+
+func TestResolveAPIKey(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("api_key_cmd cases use sh")
+	}
+
+	storePath := filepath.Join(t.TempDir(), "keys.json")
+	t.Setenv(keyring.FileEnv, storePath)
+	store := &keyring.FileStore{Path: storePath}
+	if err := store.Set(string(ProviderOpenAI), "key-keyring"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AIGIT_TEST_KEY", " key-env \n")
+	t.Setenv("AIGIT_TEST_EMPTY", "")
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    string
+		wantErr string
+	}{
+		{
+			name: "api_key wins over every other source",
+			cfg: Config{Provider: ProviderOpenAI, APIKey: "key-plain", APIKeyEnv: "AIGIT_TEST_KEY",
+				APIKeyCmd: "echo key-cmd", KeyStore: KeyStoreKeyring},
+			want: "key-plain",
+		},
+		{
+			name: "api_key_env before api_key_cmd and the keyring",
+			cfg: Config{Provider: ProviderOpenAI, APIKeyEnv: "AIGIT_TEST_KEY",
+				APIKeyCmd: "echo key-cmd", KeyStore: KeyStoreKeyring},
+			want: "key-env",
+		},
+		{
+			name:    "api_key_env that is empty",
+			cfg:     Config{Provider: ProviderOpenAI, APIKeyEnv: "AIGIT_TEST_EMPTY", KeyStore: KeyStoreKeyring},
+			wantErr: "AIGIT_TEST_EMPTY (api_key_env) is not set",
+		},
+		{
+			name: "api_key_cmd before the keyring",
+			cfg:  Config{Provider: ProviderOpenAI, APIKeyCmd: "echo key-cmd", KeyStore: KeyStoreKeyring},
+			want: "key-cmd",
+		},
+		{
+			name: "api_key_cmd keeps only the first line",
+			cfg:  Config{Provider: ProviderOpenAI, APIKeyCmd: "printf 'key-cmd\\nlogin: me\\n'"},
+			want: "key-cmd",
+		},
+		{
+			name:    "api_key_cmd that fails",
+			cfg:     Config{Provider: ProviderOpenAI, APIKeyCmd: "echo locked >&2; exit 1"},
+			wantErr: "api_key_cmd failed: locked",
+		},
+		{
+			name:    "api_key_cmd that prints nothing",
+			cfg:     Config{Provider: ProviderOpenAI, APIKeyCmd: "true"},
+			wantErr: "api_key_cmd printed no key",
+		},
+		{
+			name: "keyring",
+			cfg:  Config{Provider: ProviderOpenAI, KeyStore: KeyStoreKeyring},
+			want: "key-keyring",
+		},
+		{
+			name:    "keyring without an entry for the provider",
+			cfg:     Config{Provider: ProviderClaude, KeyStore: KeyStoreKeyring},
+			wantErr: "no API key for claude in the keyring",
+		},
+		{
+			name:    "no source",
+			cfg:     Config{Provider: ProviderOpenAI},
+			wantErr: "api_key is required",
+		},
+		{
+			name:    "no source for a profile",
+			cfg:     Config{Provider: ProviderOpenAI, profile: "work"},
+			wantErr: "api_key is required for profile work",
+		},
+		{
+			name: "no source for a local provider",
+			cfg:  Config{Provider: ProviderOllama},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.ResolveAPIKey()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveAPIKey() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveAPIKey() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveAPIKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreAPIKey(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "keys.json")
+	t.Setenv(keyring.FileEnv, storePath)
+
+	cfg := &Config{Provider: ProviderOpenAI, APIKey: "old"}
+	if err := cfg.StoreAPIKey(ProviderOpenAI, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "new" {
+		t.Errorf("file store: APIKey = %q, want %q", cfg.APIKey, "new")
+	}
+
+	cfg.KeyStore = KeyStoreKeyring
+	if err := cfg.StoreAPIKey(ProviderClaude, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "" {
+		t.Errorf("keyring store left the plaintext key %q in the config", cfg.APIKey)
+	}
+	got, err := (&keyring.FileStore{Path: storePath}).Get(string(ProviderClaude))
+	if err != nil || got != "secret" {
+		t.Errorf("keyring entry = %q, %v; want %q", got, err, "secret")
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -14,38 +15,180 @@ const (
 	ProviderClaude     Provider = "claude"
 	ProviderGoogle     Provider = "google"
 	ProviderOpenRouter Provider = "openrouter"
+	ProviderOllama     Provider = "ollama"
 )
 
 type Config struct {
 	Provider Provider `json:"provider"`
-	APIKey   string   `json:"api_key"`
+	APIKey   string   `json:"api_key,omitempty"`
 	Model    string   `json:"model"`
 	Language string   `json:"language"` // "en" or "zh"
 	BaseURL  string   `json:"base_url,omitempty"`
+	FailOn   string   `json:"fail_on,omitempty"` // "high", "medium" or "low"
+
+	// APIKeyEnv and APIKeyCmd name an environment variable or a shell
+	// command that provides the key instead of storing it in APIKey.
+	// KeyStore selects where 'aigit config api_key' saves keys: "file"
+	// (default) or "keyring".
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	APIKeyCmd string `json:"api_key_cmd,omitempty"`
+	KeyStore  string `json:"key_store,omitempty"`
+
+	// MaxTokensIn overrides the input token budget derived from the model's
+	// context window; larger diffs are split and summarised.
+	MaxTokensIn int `json:"max_tokens_in,omitempty"`
+
+	// Redact controls secret detection before diffs are sent: "mask"
+	// (default), "block" or "off". RedactPatterns adds custom regexes.
+	Redact         string   `json:"redact,omitempty"`
+	RedactPatterns []string `json:"redact_patterns,omitempty"`
+
+	// Style selects the commit message convention: "auto" (default) learns
+	// it from the repository's history; "conventional", "gitmoji" and
+	// "plain" force one.
+	Style string `json:"style,omitempty"`
+
+	// Lint configures the checks 'aigit lint-msg' applies on top of the
+	// convention selected by Style.
+	Lint Lint `json:"lint,omitzero"`
+
+	// Ignore lists gitignore-style patterns, in addition to .aigitignore,
+	// for paths whose changes are summarised instead of sent in full.
+	Ignore []string `json:"ignore,omitempty"`
+
+	// Profiles are named provider settings that replace the top-level ones
+	// when selected with --profile, or per task with CommitProfile and
+	// ReviewProfile.
+	Profiles      map[string]Profile `json:"profiles,omitempty"`
+	CommitProfile string             `json:"commit_profile,omitempty"`
+	ReviewProfile string             `json:"review_profile,omitempty"`
+
+	// Network settings shared by all providers. Timeout bounds a whole
+	// command's provider requests, retries included, as a Go duration
+	// ("90s", "2m"); by default 60s for commits and 120s for reviews.
+	// Headers are added to every request, for example for an API gateway.
+	Proxy              string            `json:"proxy,omitempty"`
+	CAFile             string            `json:"ca_file,omitempty"`
+	InsecureSkipVerify bool              `json:"insecure_skip_verify,omitempty"`
+	Timeout            string            `json:"timeout,omitempty"`
+	Headers            map[string]string `json:"headers,omitempty"`
+
+	// Fallback lists profiles or providers tried in order when the
+	// selected provider keeps failing.
+	Fallback []string `json:"fallback,omitempty"`
+
+	// origins records which layer each key was last set by.
+	origins map[string]string
+	// profile is the name of the applied profile, if any.
+	profile string
+	// base is the configuration before a profile was applied, from which
+	// fallbacks are derived.
+	base *Config
+}
+
+// Lint holds commit message rules. Zero values select the defaults.
+type Lint struct {
+	Types      []string `json:"types,omitempty"`       // allowed conventional commit types
+	Scopes     []string `json:"scopes,omitempty"`      // allowed scopes; any scope when empty
+	MaxSubject int      `json:"max_subject,omitempty"` // subject length limit, 72 by default
+	BodyWrap   int      `json:"body_wrap,omitempty"`   // body line length limit, 72 by default
+	Ticket     string   `json:"ticket,omitempty"`      // regexp of a required ticket reference
+	SignOff    bool     `json:"signoff,omitempty"`     // require a Signed-off-by trailer
 }
 
 func DefaultConfig() *Config {
 	return &Config{
 		Provider: ProviderOpenAI,
 		Language: "en",
+		FailOn:   "high",
 	}
 }
 
-func configPath() (string, error) {
+// Dir returns the directory holding the global configuration, ~/.aigit.
+func Dir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(home, ".aigit", "config.json"), nil
+	return filepath.Join(home, ".aigit"), nil
 }
 
-func Load() (*Config, error) {
-	path, err := configPath()
+func configPath() (string, error) {
+	dir, err := Dir()
 	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// Load returns the effective configuration without a task profile. See
+// LoadFor.
+func Load() (*Config, error) {
+	return LoadFor("", "")
+}
+
+// LoadFor returns the effective configuration for task. Each layer overrides
+// the one before it: built-in defaults, the global ~/.aigit/config.json, the
+// repository's .aigit.json or .aigit.yaml, the selected profile, and AIGIT_*
+// environment variables. The profile is the given one, else AIGIT_PROFILE,
+// else the task's default. Command-line flags are applied by the caller with
+// Override.
+func LoadFor(task Task, profile string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := readGlobal()
+	hasGlobal := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if hasGlobal {
+		if err := mergeFile(cfg, data, OriginGlobal); err != nil {
+			return nil, err
+		}
+		// LoadGlobal skips this, so that 'aigit config' can still
+		// correct a bad value.
+		if err := cfg.validate(); err != nil {
+			return nil, fmt.Errorf("global config: %w", err)
+		}
+	}
+
+	if err := loadRepoLayer(cfg); err != nil {
 		return nil, err
 	}
+	base := *cfg
+	base.origins = nil
+	cfg.base = &base
 
-	data, err := os.ReadFile(path)
+	if profile == "" {
+		profile = os.Getenv("AIGIT_PROFILE")
+	}
+	if profile == "" {
+		profile = cfg.taskProfile(task)
+	}
+	if err := cfg.applyProfile(profile); err != nil {
+		return nil, err
+	}
+	if err := loadEnvLayer(cfg); err != nil {
+		return nil, err
+	}
+
+	// The key itself is resolved when a client is created; see
+	// ResolveAPIKey.
+	if task != TaskLint && !hasGlobal && !cfg.HasAPIKeySource() && RequiresAPIKey(cfg.Provider) {
+		return nil, errors.New("config not found, please run 'aigit config' first")
+	}
+
+	if cfg.FailOn == "" {
+		cfg.FailOn = "high"
+	}
+
+	return cfg, nil
+}
+
+// LoadGlobal reads only the global config file, for editing it without
+// copying repository or environment values into it.
+func LoadGlobal() (*Config, error) {
+	data, err := readGlobal()
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, errors.New("config not found, please run 'aigit config' first")
@@ -53,16 +196,19 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	cfg := DefaultConfig()
+	if err := mergeFile(cfg, data, OriginGlobal); err != nil {
 		return nil, err
 	}
+	return cfg, nil
+}
 
-	if cfg.APIKey == "" {
-		return nil, errors.New("api_key is required")
+func readGlobal() ([]byte, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
 	}
-
-	return &cfg, nil
+	return os.ReadFile(path)
 }
 
 func Save(cfg *Config) error {
@@ -93,6 +239,8 @@ func GetDefaultModel(provider Provider) string {
 		return "gemini-1.5-pro"
 	case ProviderOpenRouter:
 		return "anthropic/claude-sonnet-4-20250514"
+	case ProviderOllama:
+		return "llama3.1"
 	default:
 		return ""
 	}
@@ -102,7 +250,15 @@ func GetDefaultBaseURL(provider Provider) string {
 	switch provider {
 	case ProviderOpenRouter:
 		return "https://openrouter.ai/api/v1"
+	case ProviderOllama:
+		return "http://localhost:11434"
 	default:
 		return ""
 	}
 }
+
+// RequiresAPIKey reports whether the provider is a hosted API that needs a
+// key. Local providers run on the user's machine and usually have none.
+func RequiresAPIKey(provider Provider) bool {
+	return provider != ProviderOllama
+}
@@ -0,0 +1,467 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-goll/aigit/internal/git"
+	"github.com/go-goll/aigit/internal/redact"
+	"gopkg.in/yaml.v3"
+)
+
+// Origins reported by Config.Origin for values that were not set by a file
+// or environment variable.
+const (
+	OriginDefault = "default"
+	OriginGlobal  = "global"
+)
+
+// Commit message styles for the style key.
+const (
+	StyleAuto         = "auto"
+	StyleConventional = "conventional"
+	StyleGitmoji      = "gitmoji"
+	StylePlain        = "plain"
+)
+
+// RepoConfigFiles are looked up, in order, at the repository root. At most
+// one of them may exist.
+var RepoConfigFiles = []string{".aigit.json", ".aigit.yaml", ".aigit.yml"}
+
+// repoForbidden lists keys that must not appear in the committed repository
+// file: api_key because everyone who clones the repository can read it,
+// base_url because a cloned repository could otherwise send the user's own
+// key to a server of its choosing, api_key_cmd because it would run
+// arbitrary commands, api_key_env and key_store because they would pick
+// which of the user's secrets is sent, redact because it would turn off
+// secret detection, and the network settings because a proxy combined
+// with a custom CA or disabled verification could intercept the key.
+var repoForbidden = []string{
+	"api_key", "base_url", "api_key_cmd", "api_key_env", "key_store", "redact",
+	"proxy", "ca_file", "insecure_skip_verify", "headers",
+}
+
+// envVars maps config keys to the environment variables that override them.
+var envVars = []struct {
+	key, name string
+}{
+	{"provider", "AIGIT_PROVIDER"},
+	{"api_key", "AIGIT_API_KEY"},
+	{"model", "AIGIT_MODEL"},
+	{"language", "AIGIT_LANGUAGE"},
+	{"base_url", "AIGIT_BASE_URL"},
+	{"fail_on", "AIGIT_FAIL_ON"},
+	{"max_tokens_in", "AIGIT_MAX_TOKENS_IN"},
+	{"redact", "AIGIT_REDACT"},
+	{"timeout", "AIGIT_TIMEOUT"},
+	{"style", "AIGIT_STYLE"},
+}
+
+// Origin describes which layer the value of key came from: "default",
+// "global", "repo .aigit.yaml", "env AIGIT_MODEL" or "flag --model".
+func (c *Config) Origin(key string) string {
+	if o, ok := c.origins[key]; ok {
+		return o
+	}
+	return OriginDefault
+}
+
+func (c *Config) setOrigin(key, origin string) {
+	if c.origins == nil {
+		c.origins = make(map[string]string)
+	}
+	c.origins[key] = origin
+}
+
+// Override sets key from a higher-priority layer such as a command-line
+// flag and records origin for it, and for the settings a provider switch
+// cleared.
+func (c *Config) Override(key, value, origin string) error {
+	prev := c.Provider
+	if err := c.Set(key, value); err != nil {
+		return err
+	}
+	c.setOrigin(key, origin)
+	if c.Provider != prev {
+		for _, k := range providerKeys {
+			c.setOrigin(k, origin)
+		}
+	}
+	return nil
+}
+
+// Set assigns a single value by its config key.
+func (c *Config) Set(key, value string) error {
+	switch key {
+	case "provider":
+		p := Provider(value)
+		if !IsValidProvider(p) {
+			return fmt.Errorf("invalid provider: %s (use: openai, claude, google, openrouter, ollama)", value)
+		}
+		c.switchProvider(p)
+	case "api_key":
+		c.APIKey = value
+	case "api_key_env":
+		c.APIKeyEnv = value
+	case "api_key_cmd":
+		c.APIKeyCmd = value
+	case "key_store":
+		if value != KeyStoreFile && value != KeyStoreKeyring {
+			return fmt.Errorf("invalid key_store: %s (use: file, keyring)", value)
+		}
+		c.KeyStore = value
+	case "model":
+		c.Model = value
+	case "language":
+		if value != "en" && value != "zh" {
+			return fmt.Errorf("invalid language: %s (use: en, zh)", value)
+		}
+		c.Language = value
+	case "base_url":
+		c.BaseURL = value
+	case "fail_on":
+		if !isSeverity(value) {
+			return fmt.Errorf("invalid fail_on: %s (use: high, medium, low)", value)
+		}
+		c.FailOn = value
+	case "max_tokens_in":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid max_tokens_in: %s (use a non-negative number)", value)
+		}
+		c.MaxTokensIn = n
+	case "redact":
+		if _, err := redact.ParseMode(value); err != nil {
+			return err
+		}
+		c.Redact = value
+	case "style":
+		switch value {
+		case "", StyleAuto, StyleConventional, StyleGitmoji, StylePlain:
+		default:
+			return fmt.Errorf("invalid style: %s (use: auto, conventional, gitmoji, plain)", value)
+		}
+		c.Style = value
+	case "lint.types":
+		c.Lint.Types = splitList(value)
+	case "lint.scopes":
+		c.Lint.Scopes = splitList(value)
+	case "lint.max_subject", "lint.body_wrap":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s: %s (use a non-negative number)", key, value)
+		}
+		if key == "lint.max_subject" {
+			c.Lint.MaxSubject = n
+		} else {
+			c.Lint.BodyWrap = n
+		}
+	case "lint.ticket":
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("invalid lint.ticket: %w", err)
+		}
+		c.Lint.Ticket = value
+	case "lint.signoff":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid lint.signoff: %s (use: true, false)", value)
+		}
+		c.Lint.SignOff = b
+	case "commit_profile":
+		c.CommitProfile = value
+	case "review_profile":
+		c.ReviewProfile = value
+	case "proxy":
+		if value != "" {
+			if u, err := url.Parse(value); err != nil || u.Host == "" {
+				return fmt.Errorf("invalid proxy: %s (use a URL such as http://proxy:8080)", value)
+			}
+		}
+		c.Proxy = value
+	case "ca_file":
+		c.CAFile = value
+	case "insecure_skip_verify":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid insecure_skip_verify: %s (use: true, false)", value)
+		}
+		c.InsecureSkipVerify = b
+	case "timeout":
+		if _, err := parseTimeout(value); err != nil {
+			return err
+		}
+		c.Timeout = value
+	case "fallback":
+		c.Fallback = splitList(value)
+	default:
+		if strings.HasPrefix(key, "profiles.") {
+			return c.setProfileValue(key, value)
+		}
+		if name, ok := strings.CutPrefix(key, "headers."); ok && name != "" {
+			if c.Headers == nil {
+				c.Headers = make(map[string]string)
+			}
+			if value == "" {
+				delete(c.Headers, name)
+			} else {
+				c.Headers[name] = value
+			}
+			return nil
+		}
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+	return nil
+}
+
+// isSeverity reports whether s names a severity ai.ParseSeverity accepts,
+// including its aliases.
+func isSeverity(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "high", "critical", "blocker", "高",
+		"medium", "moderate", "warning", "中",
+		"low", "minor", "info", "低":
+		return true
+	default:
+		return false
+	}
+}
+
+// splitList parses a comma-separated list, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// TimeoutOr returns the configured timeout, or def when none is set.
+func (c *Config) TimeoutOr(def time.Duration) time.Duration {
+	d, err := parseTimeout(c.Timeout)
+	if err != nil || d == 0 {
+		return def
+	}
+	return d
+}
+
+// parseTimeout accepts a Go duration or a plain number of seconds.
+func parseTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid timeout: %s (use a duration such as 90s or 2m)", value)
+	}
+	return d, nil
+}
+
+func IsValidProvider(p Provider) bool {
+	switch p {
+	case ProviderOpenAI, ProviderClaude, ProviderGoogle, ProviderOpenRouter, ProviderOllama:
+		return true
+	default:
+		return false
+	}
+}
+
+// mergeFile decodes a JSON object over cfg, leaving keys it does not
+// mention untouched, and records origin for every key it sets. A profile
+// is merged field by field, so a file that sets only profiles.work.model
+// keeps the rest of a work profile defined by a lower layer, unless it
+// switches the profile's provider.
+func mergeFile(cfg *Config, data []byte, origin string) error {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	profiles := cfg.Profiles
+	cfg.Profiles = nil
+	err := json.Unmarshal(data, cfg)
+	cfg.Profiles = profiles
+	if err != nil {
+		return err
+	}
+	if raw, ok := keys["profiles"]; ok {
+		var fields map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return err
+		}
+		for name, f := range fields {
+			p := cfg.Profiles[name]
+			prev := p.Provider
+			raw, _ := json.Marshal(f)
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return fmt.Errorf("profiles.%s: %w", name, err)
+			}
+			if p.Provider != prev {
+				p.APIKey, p.APIKeyEnv, p.APIKeyCmd, p.BaseURL = "", "", "", ""
+				if _, ok := f["model"]; !ok {
+					p.Model = ""
+				}
+			}
+			if cfg.Profiles == nil {
+				cfg.Profiles = make(map[string]Profile)
+			}
+			cfg.Profiles[name] = p
+		}
+	}
+	for key := range keys {
+		cfg.setOrigin(key, origin)
+	}
+	return nil
+}
+
+// validate applies Set's checks to the values a config file decoded
+// directly, so that a file cannot hold what 'aigit config' would reject.
+func (c *Config) validate() error {
+	for _, kv := range []struct{ key, value string }{
+		{"provider", string(c.Provider)},
+		{"language", c.Language},
+		{"key_store", c.KeyStore},
+		{"fail_on", c.FailOn},
+		{"redact", c.Redact},
+		{"style", c.Style},
+		{"timeout", c.Timeout},
+		{"proxy", c.Proxy},
+		{"lint.ticket", c.Lint.Ticket},
+	} {
+		if kv.value == "" {
+			continue
+		}
+		if err := c.Set(kv.key, kv.value); err != nil {
+			return err
+		}
+	}
+	if c.MaxTokensIn < 0 {
+		return fmt.Errorf("invalid max_tokens_in: %d (use a non-negative number)", c.MaxTokensIn)
+	}
+	if c.Lint.MaxSubject < 0 || c.Lint.BodyWrap < 0 {
+		return errors.New("invalid lint limits: max_subject and body_wrap must not be negative")
+	}
+	for _, name := range c.ProfileNames() {
+		p := c.Profiles[name]
+		if p.Provider != "" && !IsValidProvider(p.Provider) {
+			return fmt.Errorf("profiles.%s: invalid provider: %s (use: openai, claude, google, openrouter, ollama)", name, p.Provider)
+		}
+		if p.MaxTokensIn < 0 {
+			return fmt.Errorf("profiles.%s: invalid max_tokens_in: %d (use a non-negative number)", name, p.MaxTokensIn)
+		}
+	}
+	return nil
+}
+
+// loadRepoLayer merges the repository config file, if any, over cfg.
+func loadRepoLayer(cfg *Config) error {
+	root, err := git.RepoRoot()
+	if err != nil {
+		return nil
+	}
+
+	var found string
+	for _, name := range RepoConfigFiles {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			if found != "" {
+				return fmt.Errorf("both %s and %s found; keep only one", found, name)
+			}
+			found = name
+		}
+	}
+	if found == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, found))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", found, err)
+	}
+
+	// YAML is converted to JSON so both formats share the json tags.
+	if strings.HasSuffix(found, ".yaml") || strings.HasSuffix(found, ".yml") {
+		var v map[string]any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", found, err)
+		}
+		if v == nil {
+			return nil
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", found, err)
+		}
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", found, err)
+	}
+	for _, key := range repoForbidden {
+		if _, ok := keys[key]; ok {
+			return fmt.Errorf("%s must not contain %s: it is committed to the repository (set it with 'aigit config %s' instead)",
+				found, key, key)
+		}
+	}
+	if raw, ok := keys["profiles"]; ok {
+		var profiles map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &profiles); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", found, err)
+		}
+		for name, p := range profiles {
+			for _, key := range repoForbidden {
+				if _, ok := p[key]; ok {
+					return fmt.Errorf("%s must not contain profiles.%s.%s: it is committed to the repository", found, name, key)
+				}
+			}
+		}
+	}
+
+	origin := "repo " + found
+	prev := cfg.Provider
+	if err := mergeFile(cfg, data, origin); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", found, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("%s: %w", found, err)
+	}
+	// As with a profile, a repository that switches provider must not
+	// inherit the user's key, model or endpoint for another provider. The
+	// model is kept only if the file sets it.
+	if cfg.Provider != prev {
+		cfg.APIKey, cfg.APIKeyEnv, cfg.APIKeyCmd, cfg.BaseURL = "", "", "", ""
+		cleared := []string{"api_key", "api_key_env", "api_key_cmd", "base_url"}
+		if _, ok := keys["model"]; !ok {
+			cfg.Model = ""
+			cleared = append(cleared, "model")
+		}
+		for _, key := range cleared {
+			cfg.setOrigin(key, origin)
+		}
+	}
+	return nil
+}
+
+// loadEnvLayer applies AIGIT_* environment variables over cfg.
+func loadEnvLayer(cfg *Config) error {
+	for _, v := range envVars {
+		value, ok := os.LookupEnv(v.name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := cfg.Override(v.key, value, "env "+v.name); err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+	}
+	return nil
+}
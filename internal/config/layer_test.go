@@ -0,0 +1,76 @@
+package config
+
+import "testing"
+
+func TestOverrideProvider(t *testing.T) {
+	openai := func() *Config {
+		return &Config{
+			Provider:  ProviderOpenAI,
+			APIKey:    "sk-openai",
+			APIKeyEnv: "OPENAI_API_KEY",
+			APIKeyCmd: "pass openai",
+			Model:     "gpt-4o",
+			BaseURL:   "https://gateway.example.com/v1",
+			Language:  "zh",
+		}
+	}
+
+	t.Run("switch clears the previous provider's settings", func(t *testing.T) {
+		cfg := openai()
+		if err := cfg.Override("provider", "claude", "flag --provider"); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Provider != ProviderClaude {
+			t.Errorf("Provider = %s, want claude", cfg.Provider)
+		}
+		if cfg.APIKey != "" || cfg.APIKeyEnv != "" || cfg.APIKeyCmd != "" || cfg.Model != "" || cfg.BaseURL != "" {
+			t.Errorf("OpenAI settings survived the switch: %+v", cfg)
+		}
+		if cfg.Language != "zh" {
+			t.Errorf("Language = %q, want the unrelated setting kept", cfg.Language)
+		}
+		for _, key := range append([]string{"provider"}, providerKeys...) {
+			if got := cfg.Origin(key); got != "flag --provider" {
+				t.Errorf("Origin(%s) = %q, want %q", key, got, "flag --provider")
+			}
+		}
+	})
+
+	t.Run("same provider keeps the settings", func(t *testing.T) {
+		cfg := openai()
+		if err := cfg.Override("provider", "openai", "flag --provider"); err != nil {
+			t.Fatal(err)
+		}
+		if want := openai(); cfg.APIKey != want.APIKey || cfg.Model != want.Model || cfg.BaseURL != want.BaseURL {
+			t.Errorf("settings changed without a provider switch: %+v", cfg)
+		}
+		if got := cfg.Origin("api_key"); got != OriginDefault {
+			t.Errorf("Origin(api_key) = %q, want it untouched", got)
+		}
+	})
+
+	t.Run("invalid provider changes nothing", func(t *testing.T) {
+		cfg := openai()
+		if err := cfg.Override("provider", "nope", "flag --provider"); err == nil {
+			t.Fatal("Override accepted an invalid provider")
+		}
+		if cfg.Provider != ProviderOpenAI || cfg.APIKey != "sk-openai" {
+			t.Errorf("failed override changed the config: %+v", cfg)
+		}
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("AIGIT_PROVIDER", "openrouter")
+		t.Setenv("AIGIT_API_KEY", "sk-or")
+		cfg := openai()
+		if err := loadEnvLayer(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Provider != ProviderOpenRouter || cfg.APIKey != "sk-or" {
+			t.Errorf("Provider, APIKey = %s, %q; want openrouter with the key from AIGIT_API_KEY", cfg.Provider, cfg.APIKey)
+		}
+		if cfg.BaseURL != "" || cfg.Model != "" || cfg.APIKeyEnv != "" {
+			t.Errorf("OpenAI settings survived AIGIT_PROVIDER: %+v", cfg)
+		}
+	})
+}
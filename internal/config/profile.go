@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Task identifies the operation a configuration is loaded for.
+type Task string
+
+const (
+	TaskCommit Task = "commit"
+	TaskReview Task = "review"
+	// TaskLint checks commit messages. It uses the commit profile but, as
+	// most checks need no provider, works without any configuration.
+	TaskLint Task = "lint"
+)
+
+// Profile holds the provider settings that can differ between profiles.
+// Empty fields fall back to the top-level values.
+type Profile struct {
+	Provider    Provider `json:"provider,omitempty"`
+	APIKey      string   `json:"api_key,omitempty"`
+	APIKeyEnv   string   `json:"api_key_env,omitempty"`
+	APIKeyCmd   string   `json:"api_key_cmd,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	BaseURL     string   `json:"base_url,omitempty"`
+	MaxTokensIn int      `json:"max_tokens_in,omitempty"`
+}
+
+// ActiveProfile returns the name of the applied profile, or "" if the
+// top-level settings are used.
+func (c *Config) ActiveProfile() string {
+	return c.profile
+}
+
+// ProfileNames returns the defined profiles in sorted order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Config) taskProfile(task Task) string {
+	switch task {
+	case TaskCommit, TaskLint:
+		return c.CommitProfile
+	case TaskReview:
+		return c.ReviewProfile
+	default:
+		return ""
+	}
+}
+
+// providerKeys are the settings that belong to one provider and are not
+// carried over when another provider is selected.
+var providerKeys = []string{"api_key", "api_key_env", "api_key_cmd", "model", "base_url"}
+
+// switchProvider selects p. If that changes the provider, the key, model
+// and endpoint of the previous one are cleared, so that they are never
+// sent to another vendor; it reports whether it did.
+func (c *Config) switchProvider(p Provider) bool {
+	if p == c.Provider {
+		return false
+	}
+	c.Provider = p
+	c.APIKey, c.APIKeyEnv, c.APIKeyCmd, c.Model, c.BaseURL = "", "", "", "", ""
+	return true
+}
+
+// applyProfile copies the non-empty fields of the named profile over the
+// top-level settings.
+func (c *Config) applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return fmt.Errorf("unknown profile: %s (no profiles are defined)", name)
+		}
+		return fmt.Errorf("unknown profile: %s (available: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	origin := "profile " + name
+	if p.Provider != "" {
+		if !IsValidProvider(p.Provider) {
+			return fmt.Errorf("profile %s: invalid provider: %s", name, p.Provider)
+		}
+		if c.switchProvider(p.Provider) {
+			for _, key := range providerKeys {
+				c.setOrigin(key, origin)
+			}
+		}
+		c.setOrigin("provider", origin)
+	}
+	// A profile's key source replaces the top-level one entirely.
+	if p.APIKey != "" || p.APIKeyEnv != "" || p.APIKeyCmd != "" {
+		c.APIKey, c.APIKeyEnv, c.APIKeyCmd = p.APIKey, p.APIKeyEnv, p.APIKeyCmd
+		for _, key := range []string{"api_key", "api_key_env", "api_key_cmd"} {
+			c.setOrigin(key, origin)
+		}
+	}
+	if p.Model != "" {
+		c.Model = p.Model
+		c.setOrigin("model", origin)
+	}
+	if p.BaseURL != "" {
+		c.BaseURL = p.BaseURL
+		c.setOrigin("base_url", origin)
+	}
+	if p.MaxTokensIn > 0 {
+		c.MaxTokensIn = p.MaxTokensIn
+		c.setOrigin("max_tokens_in", origin)
+	}
+	c.profile = name
+	return nil
+}
+
+// Fallbacks returns the configurations of the Fallback entries, in order.
+// An entry names a profile or, if no profile has that name, a provider used
+// with its default model and key store.
+func (c *Config) Fallbacks() ([]*Config, error) {
+	base := c
+	if c.base != nil {
+		base = c.base
+	}
+
+	var configs []*Config
+	for _, name := range c.Fallback {
+		fc := *base
+		fc.origins, fc.base, fc.profile = nil, nil, ""
+
+		if _, ok := c.Profiles[name]; ok {
+			if err := fc.applyProfile(name); err != nil {
+				return nil, err
+			}
+		} else if p := Provider(name); IsValidProvider(p) {
+			fc.switchProvider(p)
+		} else {
+			return nil, fmt.Errorf("unknown fallback: %s (use a profile or provider name)", name)
+		}
+		configs = append(configs, &fc)
+	}
+	return configs, nil
+}
+
+// setProfileValue handles "profiles.<name>.<key>" keys for Set.
+func (c *Config) setProfileValue(key, value string) error {
+	rest := strings.TrimPrefix(key, "profiles.")
+	name, field, ok := strings.Cut(rest, ".")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid profile key: %s (use: profiles.<name>.<key>)", key)
+	}
+
+	p := c.Profiles[name]
+	switch field {
+	case "provider":
+		if !IsValidProvider(Provider(value)) {
+			return fmt.Errorf("invalid provider: %s (use: openai, claude, google, openrouter, ollama)", value)
+		}
+		p.Provider = Provider(value)
+	case "api_key":
+		p.APIKey = value
+	case "api_key_env":
+		p.APIKeyEnv = value
+	case "api_key_cmd":
+		p.APIKeyCmd = value
+	case "model":
+		p.Model = value
+	case "base_url":
+		p.BaseURL = value
+	case "max_tokens_in":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid max_tokens_in: %s (use a non-negative number)", value)
+		}
+		p.MaxTokensIn = n
+	default:
+		return fmt.Errorf("unknown profile key: %s (use: provider, api_key, api_key_env, api_key_cmd, model, base_url, max_tokens_in)", field)
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]Profile)
+	}
+	c.Profiles[name] = p
+	return nil
+}
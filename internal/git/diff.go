@@ -0,0 +1,206 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type FileDiff struct {
+	OldPath  string
+	NewPath  string
+	Header   []string // "diff --git" line and extended headers up to the first hunk
+	IsBinary bool
+	Hunks    []Hunk
+}
+
+type Hunk struct {
+	Header   string // the "@@ -a,b +c,d @@" line
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []string // hunk body, each line keeps its ' ', '+', '-' or '\' prefix
+}
+
+// Path returns the path the file has after the change, or the old path for
+// deletions.
+func (f FileDiff) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// String reassembles the file diff into a patch that git apply accepts.
+func (f FileDiff) String() string {
+	var b strings.Builder
+	for _, line := range f.Header {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	for _, h := range f.Hunks {
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
+
+// Patch returns a patch applying only the hunks of f with the given
+// indexes. With header, f's own header is used, carrying any creation,
+// deletion, rename or mode change; without it, a plain header for the new
+// path is used, for hunks applied after an earlier part of the file.
+func (f FileDiff) Patch(hunks []int, header bool) string {
+	var b strings.Builder
+	if header {
+		for _, line := range f.Header {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	} else {
+		a, bp := quotePath("a/"+f.Path()), quotePath("b/"+f.Path())
+		fmt.Fprintf(&b, "diff --git %s %s\n--- %s\n+++ %s\n", a, bp, a, bp)
+	}
+	for _, i := range hunks {
+		b.WriteString(f.Hunks[i].String())
+	}
+	return b.String()
+}
+
+func (h Hunk) String() string {
+	var b strings.Builder
+	b.WriteString(h.Header)
+	b.WriteByte('\n')
+	for _, line := range h.Lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// NewEnd returns the last new-side line covered by the hunk.
+func (h Hunk) NewEnd() int {
+	if h.NewLines == 0 {
+		return h.NewStart
+	}
+	return h.NewStart + h.NewLines - 1
+}
+
+// ParseDiff splits unified diff output from git into files and hunks. Lines
+// outside any file section, such as the "=== Staged Changes ===" banners
+// GetAllDiff inserts, are ignored.
+func ParseDiff(diff string) []FileDiff {
+	var files []FileDiff
+	var file *FileDiff
+	var hunk *Hunk
+	var oldLeft, newLeft int // body lines the current hunk header still announces
+
+	flush := func() {
+		if file == nil {
+			return
+		}
+		if hunk != nil {
+			file.Hunks = append(file.Hunks, *hunk)
+			hunk = nil
+		}
+		files = append(files, *file)
+		file = nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			file = &FileDiff{Header: []string{line}}
+			file.OldPath, file.NewPath = parseDiffGitLine(line)
+		case file == nil:
+			continue
+		case strings.HasPrefix(line, "@@"):
+			if hunk != nil {
+				file.Hunks = append(file.Hunks, *hunk)
+			}
+			hunk = &Hunk{Header: line}
+			hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines = parseHunkHeader(line)
+			oldLeft, newLeft = hunk.OldLines, hunk.NewLines
+		case hunk != nil && (oldLeft > 0 || newLeft > 0 || strings.HasPrefix(line, "\\")):
+			hunk.Lines = append(hunk.Lines, line)
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, "\\"):
+			default:
+				oldLeft--
+				newLeft--
+			}
+		case hunk != nil:
+			// Anything after a complete hunk that is not a new hunk or file
+			// belongs to whatever wrapped the diff, not to this file.
+			flush()
+		default:
+			file.Header = append(file.Header, line)
+			switch {
+			case strings.HasPrefix(line, "--- "):
+				file.OldPath = diffPath(strings.TrimPrefix(line, "--- "))
+			case strings.HasPrefix(line, "+++ "):
+				file.NewPath = diffPath(strings.TrimPrefix(line, "+++ "))
+			case strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+				file.IsBinary = true
+			}
+		}
+	}
+	flush()
+
+	return files
+}
+
+func parseDiffGitLine(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.Index(rest, " b/"); i >= 0 {
+		return diffPath(rest[:i]), diffPath(rest[i+1:])
+	}
+	return "", ""
+}
+
+func diffPath(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "/dev/null" {
+		return ""
+	}
+	if unquoted, err := strconv.Unquote(p); err == nil {
+		p = unquoted
+	}
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		return p[2:]
+	}
+	return p
+}
+
+// quotePath quotes a path the way git does when it contains characters
+// that cannot appear bare in a patch header.
+func quotePath(p string) string {
+	if strings.ContainsAny(p, "\"\\\t\n") {
+		return strconv.Quote(p)
+	}
+	return p
+}
+
+func parseHunkHeader(line string) (oldStart, oldLines, newStart, newLines int) {
+	var oldRange, newRange string
+	if _, err := fmt.Sscanf(line, "@@ %s %s @@", &oldRange, &newRange); err != nil {
+		return 0, 0, 0, 0
+	}
+	oldStart, oldLines = parseRange(strings.TrimPrefix(oldRange, "-"))
+	newStart, newLines = parseRange(strings.TrimPrefix(newRange, "+"))
+	return oldStart, oldLines, newStart, newLines
+}
+
+func parseRange(r string) (int, int) {
+	start, count, found := strings.Cut(r, ",")
+	s, _ := strconv.Atoi(start)
+	if !found {
+		return s, 1
+	}
+	c, _ := strconv.Atoi(count)
+	return s, c
+}
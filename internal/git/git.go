@@ -3,15 +3,89 @@ package git
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
+// runGit runs a git command and returns its stdout, folding stderr into the
+// error so callers can surface git's own explanation.
+func runGit(args ...string) (string, error) {
+	return runGitWith(nil, "", args...)
+}
+
+// runGitWith runs git like runGit, with extra environment variables and
+// stdin as its standard input.
+func runGitWith(env []string, stdin string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", err
+	}
+	return out.String(), nil
+}
+
 func IsGitRepo() bool {
 	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
 	return cmd.Run() == nil
 }
 
+// RepoRoot returns the top-level directory of the working tree.
+func RepoRoot() (string, error) {
+	out, err := runGit("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// HooksDir returns the directory git runs hooks from: core.hooksPath, taken
+// relative to the top of the working tree, or else the hooks directory of
+// the common git dir, which linked worktrees share. custom reports whether
+// core.hooksPath is set.
+func HooksDir() (dir string, custom bool, err error) {
+	if out, err := runGit("config", "--type=path", "core.hooksPath"); err == nil {
+		if dir := strings.TrimSpace(out); dir != "" {
+			if !filepath.IsAbs(dir) {
+				root, err := RepoRoot()
+				if err != nil {
+					return "", false, err
+				}
+				dir = filepath.Join(root, dir)
+			}
+			return dir, true, nil
+		}
+	}
+
+	out, err := runGit("rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", false, err
+	}
+	common := strings.TrimSpace(out)
+	if !filepath.IsAbs(common) {
+		// Relative to the current directory, as git prints it.
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", false, err
+		}
+		common = filepath.Join(wd, common)
+	}
+	return filepath.Join(common, "hooks"), false, nil
+}
+
 func GetStagedDiff() (string, error) {
 	cmd := exec.Command("git", "diff", "--cached")
 	var out bytes.Buffer
@@ -83,6 +157,13 @@ func Commit(message string) error {
 	return cmd.Run()
 }
 
+// AddNote attaches message to rev under refs/notes/<ref>, replacing any
+// note already there.
+func AddNote(ref, rev, message string) error {
+	_, err := runGit("notes", "--ref="+ref, "add", "-f", "-m", message, rev)
+	return err
+}
+
 func StageAll() error {
 	cmd := exec.Command("git", "add", "-A")
 	return cmd.Run()
@@ -0,0 +1,215 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is the per-repository list of paths kept out of AI input.
+const IgnoreFile = ".aigitignore"
+
+type ignorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+	// anchored patterns match the whole path from the repository root;
+	// the others match any single path component.
+	anchored bool
+}
+
+// IgnoreMatcher decides which paths are excluded from AI input, using
+// gitignore syntax.
+type IgnoreMatcher struct {
+	patterns []ignorePattern
+}
+
+// LoadIgnore builds a matcher from the repository's .aigitignore followed by
+// extra patterns (for example from the config file). A missing file is not
+// an error.
+func LoadIgnore(extra []string) (*IgnoreMatcher, error) {
+	var lines []string
+
+	if root, err := RepoRoot(); err == nil {
+		f, err := os.Open(filepath.Join(root, IgnoreFile))
+		if err == nil {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			f.Close()
+			if err := scanner.Err(); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+		}
+	}
+
+	return NewIgnoreMatcher(append(lines, extra...))
+}
+
+func NewIgnoreMatcher(lines []string) (*IgnoreMatcher, error) {
+	m := &IgnoreMatcher{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p ignorePattern
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		p.anchored = strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", line, err)
+		}
+		p.re = re
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// Match reports whether path (relative to the repository root, with forward
+// slashes) is excluded. As in gitignore, the last matching pattern wins and
+// a pattern matching a directory excludes everything below it.
+func (m *IgnoreMatcher) Match(path string) bool {
+	if m == nil || len(m.patterns) == 0 {
+		return false
+	}
+
+	parts := strings.Split(path, "/")
+	ignored := false
+	for _, p := range m.patterns {
+		for i := range parts {
+			isDir := i < len(parts)-1
+			if p.dirOnly && !isDir {
+				continue
+			}
+			candidate := parts[i]
+			if p.anchored {
+				candidate = strings.Join(parts[:i+1], "/")
+			}
+			if p.re.MatchString(candidate) {
+				ignored = !p.negate
+				break
+			}
+		}
+	}
+	return ignored
+}
+
+// FilterDiff removes the sections of excluded files from a unified diff and
+// puts a one-line summary in their place. Text outside file sections, such
+// as headings or commit context, is kept.
+func (m *IgnoreMatcher) FilterDiff(diff string) string {
+	if m == nil || len(m.patterns) == 0 {
+		return diff
+	}
+
+	var b strings.Builder
+	var skipping, inHunks bool
+	var skippedPath string
+	var changed int
+
+	flush := func() {
+		if skipping {
+			unit := "lines"
+			if changed == 1 {
+				unit = "line"
+			}
+			fmt.Fprintf(&b, "# %d %s changed in %s (excluded from AI input)\n", changed, unit, skippedPath)
+		}
+		skipping, inHunks, changed = false, false, 0
+	}
+
+	lines := strings.SplitAfter(diff, "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			oldPath, newPath := parseDiffGitLine(strings.TrimRight(line, "\n"))
+			path := newPath
+			if path == "" {
+				path = oldPath
+			}
+			if m.Match(path) || (oldPath != "" && m.Match(oldPath)) {
+				skipping, skippedPath = true, path
+				continue
+			}
+		}
+
+		if skipping {
+			switch {
+			case strings.HasPrefix(line, "=== "):
+				flush()
+			case strings.HasPrefix(line, "@@"):
+				inHunks = true
+				continue
+			case inHunks && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")):
+				changed++
+				continue
+			default:
+				continue
+			}
+		}
+
+		b.WriteString(line)
+	}
+	flush()
+
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GetStagedPatch returns the staged changes as a patch that git apply can
+// replay exactly, binary files included.
+func GetStagedPatch() (string, error) {
+	return runGit("diff", "--cached", "--binary", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/")
+}
+
+// Head returns the commit HEAD points to, or "" on an unborn branch.
+func Head() string {
+	rev, err := revParse("HEAD")
+	if err != nil {
+		return ""
+	}
+	return rev
+}
+
+// ResetHead moves the current branch back to rev, leaving the index and
+// working tree alone. An empty rev makes the branch unborn again.
+func ResetHead(rev string) error {
+	if rev == "" {
+		_, err := runGit("update-ref", "-d", "HEAD")
+		return err
+	}
+	_, err := runGit("reset", "--soft", rev)
+	return err
+}
+
+// SaveIndex copies the index file aside and returns a function that puts
+// it back, for undoing an operation that went wrong halfway.
+func SaveIndex() (restore func() error, err error) {
+	out, err := runGit("rev-parse", "--git-path", "index")
+	if err != nil {
+		return nil, err
+	}
+	path := strings.TrimSpace(out)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return func() error {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			return nil
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	return func() error { return os.WriteFile(path, data, 0644) }, nil
+}
+
+// TempIndex is a scratch index file for building commits from part of the
+// staged changes without touching the real index.
+type TempIndex struct {
+	dir string
+	env []string
+}
+
+func NewTempIndex() (*TempIndex, error) {
+	dir, err := os.MkdirTemp("", "aigit-index-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	return &TempIndex{dir: dir, env: []string{"GIT_INDEX_FILE=" + filepath.Join(dir, "index")}}, nil
+}
+
+// Reset makes the index match HEAD, or empties it on an unborn branch.
+func (t *TempIndex) Reset() error {
+	var err error
+	if Head() == "" {
+		_, err = runGitWith(t.env, "", "read-tree", "--empty")
+	} else {
+		_, err = runGitWith(t.env, "", "read-tree", "HEAD")
+	}
+	return err
+}
+
+// Apply stages patch in the index.
+func (t *TempIndex) Apply(patch string) error {
+	_, err := runGitWith(t.env, patch, "apply", "--cached", "--whitespace=nowarn", "-")
+	return err
+}
+
+// Commit records the index as a new commit on the current branch. Hooks run
+// against this index.
+func (t *TempIndex) Commit(message string) error {
+	_, err := runGitWith(t.env, "", "commit", "--quiet", "-m", message)
+	return err
+}
+
+func (t *TempIndex) Remove() {
+	os.RemoveAll(t.dir)
+}
@@ -0,0 +1,66 @@
+package git
+
+import (
+	"os"
+	"strings"
+)
+
+// Editor returns the editor git uses for commit messages: GIT_EDITOR,
+// core.editor, VISUAL, EDITOR, then vi.
+func Editor() string {
+	if out, err := runGit("var", "GIT_EDITOR"); err == nil {
+		if editor := strings.TrimSpace(out); editor != "" {
+			return editor
+		}
+	}
+	for _, env := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		if editor := os.Getenv(env); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
+
+// CommentChar returns the character that starts comment lines in commit
+// messages (core.commentChar, "#" by default).
+func CommentChar() string {
+	out, err := runGit("config", "core.commentChar")
+	c := strings.TrimSpace(out)
+	if err != nil || c == "" || c == "auto" {
+		return "#"
+	}
+	return c
+}
+
+// CleanupMessage applies git's default "strip" cleanup to an edited commit
+// message: everything below a scissors line (as written by commit -v),
+// comment lines and trailing whitespace are removed, and leading, trailing
+// and repeated blank lines are collapsed.
+func CleanupMessage(message, commentChar string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(message, "\n") {
+		if line == commentChar+" ------------------------ >8 ------------------------" {
+			break
+		}
+		if strings.HasPrefix(line, commentChar) {
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GetStagedStat returns the diffstat of the staged changes.
+func GetStagedStat() (string, error) {
+	return runGit("diff", "--cached", "--stat")
+}
@@ -0,0 +1,69 @@
+package git
+
+import "testing"
+
+func TestCleanupMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		commentChar string
+		want        string
+	}{
+		{
+			name:        "already clean",
+			message:     "feat: add lint\n\nBody line.",
+			commentChar: "#",
+			want:        "feat: add lint\n\nBody line.",
+		},
+		{
+			name:        "comments are removed",
+			message:     "feat: add lint\n# Please enter the commit message\n#\n\nBody line.\n# On branch main",
+			commentChar: "#",
+			want:        "feat: add lint\n\nBody line.",
+		},
+		{
+			name:        "custom comment character",
+			message:     "; comment\nfeat: add lint\n# not a comment",
+			commentChar: ";",
+			want:        "feat: add lint\n# not a comment",
+		},
+		{
+			name:        "trailing whitespace is trimmed",
+			message:     "feat: add lint  \t\r\n\nBody line. ",
+			commentChar: "#",
+			want:        "feat: add lint\n\nBody line.",
+		},
+		{
+			name:        "leading, trailing and repeated blank lines are collapsed",
+			message:     "\n\n  \nfeat: add lint\n\n\n\nBody line.\n\n\n",
+			commentChar: "#",
+			want:        "feat: add lint\n\nBody line.",
+		},
+		{
+			name:        "everything below the scissors line is dropped",
+			message:     "feat: add lint\n# ------------------------ >8 ------------------------\ndiff --git a/x b/x\n+added",
+			commentChar: "#",
+			want:        "feat: add lint",
+		},
+		{
+			name:        "indentation is kept",
+			message:     "feat: add lint\n\n    code sample",
+			commentChar: "#",
+			want:        "feat: add lint\n\n    code sample",
+		},
+		{
+			name:        "only comments",
+			message:     "# Please enter the commit message\n#\n",
+			commentChar: "#",
+			want:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanupMessage(tt.message, tt.commentChar); got != tt.want {
+				t.Errorf("CleanupMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
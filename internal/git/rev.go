@@ -0,0 +1,174 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// emptyTree is the well-known id of git's empty tree, used as the base when
+// diffing a root commit.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// RevRange is a resolved diff range: the changes from Base to Head.
+type RevRange struct {
+	Base string
+	Head string
+}
+
+type CommitInfo struct {
+	Hash    string
+	Subject string
+	Body    string
+}
+
+// ShortHash returns the abbreviated commit id used in listings.
+func (c CommitInfo) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+func revParse(rev string) (string, error) {
+	out, err := runGit("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision: %s", rev)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func MergeBase(a, b string) (string, error) {
+	out, err := runGit("merge-base", a, b)
+	if err != nil {
+		return "", fmt.Errorf("no merge base between %s and %s", a, b)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ResolveRange turns a revision spec into a RevRange:
+//
+//	<rev>   the changes introduced by that single commit
+//	A..B    the changes from A to B
+//	A...B   the changes on B since it diverged from A
+//
+// An empty side of a range means HEAD.
+func ResolveRange(spec string) (*RevRange, error) {
+	if a, b, ok := strings.Cut(spec, "..."); ok {
+		head, err := revParse(orHead(b))
+		if err != nil {
+			return nil, err
+		}
+		base, err := MergeBase(orHead(a), head)
+		if err != nil {
+			return nil, err
+		}
+		return &RevRange{Base: base, Head: head}, nil
+	}
+
+	if a, b, ok := strings.Cut(spec, ".."); ok {
+		base, err := revParse(orHead(a))
+		if err != nil {
+			return nil, err
+		}
+		head, err := revParse(orHead(b))
+		if err != nil {
+			return nil, err
+		}
+		return &RevRange{Base: base, Head: head}, nil
+	}
+
+	head, err := revParse(spec)
+	if err != nil {
+		return nil, err
+	}
+	base, err := revParse(head + "^")
+	if err != nil {
+		base = emptyTree
+	}
+	return &RevRange{Base: base, Head: head}, nil
+}
+
+// ResolveBranchRange returns the changes HEAD introduces since it diverged
+// from base, as a pull request against base would show them.
+func ResolveBranchRange(base string) (*RevRange, error) {
+	return ResolveRange(base + "...HEAD")
+}
+
+func orHead(rev string) string {
+	if rev == "" {
+		return "HEAD"
+	}
+	return rev
+}
+
+func GetRangeDiff(r *RevRange) (string, error) {
+	return runGit("diff", r.Base, r.Head)
+}
+
+// GetRangeCommits lists the commits in the range, oldest first.
+func GetRangeCommits(r *RevRange) ([]CommitInfo, error) {
+	args := []string{"log", "--reverse", "--format=%H%x00%s%x00%b%x1e"}
+	if r.Base == emptyTree {
+		args = append(args, "-1", r.Head)
+	} else {
+		args = append(args, r.Base+".."+r.Head)
+	}
+
+	out, err := runGit(args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommits(out), nil
+}
+
+// RecentCommits lists up to n commits reachable from HEAD, newest first,
+// skipping merges. A repository without commits yields none.
+func RecentCommits(n int) ([]CommitInfo, error) {
+	return RecentCommitsFrom("HEAD", n)
+}
+
+// RecentCommitsFrom lists up to n commits reachable from rev like
+// RecentCommits. An empty or unborn rev yields none.
+func RecentCommitsFrom(rev string, n int) ([]CommitInfo, error) {
+	if rev == "" {
+		return nil, nil
+	}
+	if _, err := revParse(rev); err != nil {
+		return nil, nil
+	}
+	out, err := runGit("log", "--no-merges", "-n", strconv.Itoa(n), "--format=%H%x00%s%x00%b%x1e", rev)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommits(out), nil
+}
+
+// CurrentBranch returns the checked-out branch, or "" on a detached HEAD.
+func CurrentBranch() string {
+	out, err := runGit("symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+func parseCommits(out string) []CommitInfo {
+	var commits []CommitInfo
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x00", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		commits = append(commits, CommitInfo{
+			Hash:    fields[0],
+			Subject: fields[1],
+			Body:    strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits
+}
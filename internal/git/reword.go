@@ -0,0 +1,115 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsAncestor reports whether commit a is an ancestor of, or the same as, b.
+func IsAncestor(a, b string) bool {
+	_, err := runGit("merge-base", "--is-ancestor", a, b)
+	return err == nil
+}
+
+// Upstream returns the upstream of the current branch, such as
+// "origin/main", or "" when none is configured.
+func Upstream() string {
+	out, err := runGit("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// Parent returns the first parent of rev, or "" for a root commit.
+func Parent(rev string) string {
+	parent, err := revParse(rev + "^")
+	if err != nil {
+		return ""
+	}
+	return parent
+}
+
+// HasMerges reports whether any commit after base up to HEAD is a merge. An
+// empty base means all of HEAD's history.
+func HasMerges(base string) (bool, error) {
+	out, err := runGit("rev-list", "--merges", "-1", revisions(base))
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// AmendMessage replaces the message of HEAD. Staged changes are left out of
+// the commit and hooks are not run, since the content does not change.
+func AmendMessage(message string) error {
+	_, err := runGitWith(nil, message, "commit", "--amend", "--only", "--no-verify", "--allow-empty", "-F", "-")
+	return err
+}
+
+// RewordCommits replaces the messages of commits after base up to HEAD, keyed
+// by full hash, with an interactive rebase that runs from a generated todo
+// list: every commit is picked unchanged and the reworded ones are amended
+// straight after. An empty base rewrites from the root commit. Local changes
+// are stashed for the duration. If the rebase stops, it is aborted and HEAD
+// is left as it was; an abort that fails too is reported with the error.
+func RewordCommits(base string, messages map[string]string) error {
+	out, err := runGit("rev-list", "--reverse", revisions(base))
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "aigit-reword-")
+	if err != nil {
+		return fmt.Errorf("failed to create rebase plan: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var todo strings.Builder
+	for i, hash := range strings.Fields(out) {
+		fmt.Fprintf(&todo, "pick %s\n", hash)
+		message, ok := messages[hash]
+		if !ok {
+			continue
+		}
+		file := filepath.Join(dir, fmt.Sprintf("message-%d", i))
+		if err := os.WriteFile(file, []byte(message), 0600); err != nil {
+			return fmt.Errorf("failed to create rebase plan: %w", err)
+		}
+		fmt.Fprintf(&todo, "exec git commit --amend --only --no-verify --allow-empty -F %s\n", shellQuote(file))
+	}
+	todoFile := filepath.Join(dir, "todo")
+	if err := os.WriteFile(todoFile, []byte(todo.String()), 0600); err != nil {
+		return fmt.Errorf("failed to create rebase plan: %w", err)
+	}
+
+	args := []string{"rebase", "--interactive", "--autostash", "--no-autosquash"}
+	if base == "" {
+		args = append(args, "--root")
+	} else {
+		args = append(args, base)
+	}
+	env := []string{"GIT_SEQUENCE_EDITOR=cp " + shellQuote(todoFile), "GIT_EDITOR=:"}
+	if _, err := runGitWith(env, "", args...); err != nil {
+		if _, abortErr := runGit("rebase", "--abort"); abortErr != nil {
+			return fmt.Errorf("%w; the rebase could not be aborted either (%v), run 'git rebase --abort' or 'git rebase --continue'", err, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func revisions(base string) string {
+	if base == "" {
+		return "HEAD"
+	}
+	return base + "..HEAD"
+}
+
+// shellQuote quotes s for the POSIX shell git runs editors and exec lines
+// with.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
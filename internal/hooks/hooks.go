@@ -0,0 +1,276 @@
+package hooks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// State describes a hook file as far as aigit is concerned.
+type State int
+
+const (
+	Missing   State = iota // no hook file
+	Foreign                // a hook without an aigit block
+	Installed              // a hook that only runs the aigit block
+	Chained                // an aigit block next to another hook
+	Legacy                 // a whole-file hook written by older aigit versions
+)
+
+func (s State) String() string {
+	switch s {
+	case Missing:
+		return "not installed"
+	case Foreign:
+		return "not installed (another hook exists)"
+	case Installed:
+		return "installed"
+	case Chained:
+		return "installed, chained with an existing hook"
+	case Legacy:
+		return "installed by an older aigit; reinstall to upgrade"
+	default:
+		return "unknown"
+	}
+}
+
+// OrigSuffix is appended to the name of a hook that is not a shell script
+// when aigit moves it aside to run it from a dispatcher.
+const OrigSuffix = ".aigit-orig"
+
+// legacySuffix is where older aigit versions moved an existing hook.
+const legacySuffix = ".backup"
+
+const dispatcherLine = "# aigit: run the original hook first"
+
+var shells = map[string]bool{"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true, "ash": true, "mksh": true}
+
+func beginMarker(name string) string { return "# >>> aigit " + name + " >>>" }
+func endMarker(name string) string   { return "# <<< aigit " + name + " <<<" }
+
+// block wraps body in the markers aigit finds its code by. The body runs
+// in a subshell, so it can exit without skipping the rest of the hook; a
+// non-zero exit aborts the git command.
+func block(name, body string) string {
+	return beginMarker(name) + "\n" +
+		"# Managed by aigit; 'aigit hooks uninstall --type " + name + "' removes it.\n" +
+		"(\n" + strings.TrimRight(body, "\n") + "\n) || exit 1\n" +
+		endMarker(name) + "\n"
+}
+
+// findBlock returns the byte range of the aigit block in content, from the
+// start of its first line to the end of its last.
+func findBlock(content, name string) (start, end int, ok bool) {
+	start = strings.Index(content, beginMarker(name)+"\n")
+	if start < 0 || (start > 0 && content[start-1] != '\n') {
+		return 0, 0, false
+	}
+	n := strings.Index(content[start:], "\n"+endMarker(name))
+	if n < 0 {
+		return 0, 0, false
+	}
+	end = start + n + 1 + len(endMarker(name))
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return start, end, true
+}
+
+// isShellScript reports whether a block of shell code can be inserted into
+// content. Scripts without a shebang are run by git with /bin/sh.
+func isShellScript(content []byte) bool {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return false
+	}
+	line, _, _ := bytes.Cut(content, []byte("\n"))
+	if !bytes.HasPrefix(line, []byte("#!")) {
+		return true
+	}
+	fields := strings.Fields(string(line[2:]))
+	if len(fields) == 0 {
+		return false
+	}
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		interp = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				interp = filepath.Base(f)
+				break
+			}
+		}
+	}
+	return shells[interp]
+}
+
+// Inspect returns the state of the named hook in dir and whether git can
+// run it.
+func Inspect(dir, name string) (state State, executable bool, err error) {
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return Missing, false, nil
+	}
+	if err != nil {
+		return Missing, false, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Missing, false, err
+	}
+	return inspect(string(content), name), info.Mode()&0111 != 0, nil
+}
+
+func inspect(content, name string) State {
+	start, end, ok := findBlock(content, name)
+	switch {
+	case ok:
+		rest := content[:start] + content[end:]
+		if strings.Contains(rest, dispatcherLine) || !onlyShebang(rest) {
+			return Chained
+		}
+		return Installed
+	case isLegacy(content, name):
+		return Legacy
+	default:
+		return Foreign
+	}
+}
+
+// onlyShebang reports whether a script has nothing but its shebang.
+func onlyShebang(content string) bool {
+	content = strings.TrimSpace(content)
+	return content == "" || (strings.HasPrefix(content, "#!") && !strings.Contains(content, "\n"))
+}
+
+// Install adds the aigit block with body to the named hook in dir and
+// returns the state the hook was in. A missing hook is created; the block
+// of an installed hook is replaced. The block is inserted at the top of an
+// existing shell hook, which keeps running after it. Other hooks are moved
+// to <name>.aigit-orig and run first by a dispatcher script. Unmodified
+// hooks written by older aigit versions are replaced, chaining the hook
+// they had backed up.
+func Install(dir, name, body string) (State, error) {
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return Missing, write(path, "#!/bin/sh\n"+block(name, body))
+	}
+	if err != nil {
+		return Missing, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Missing, fmt.Errorf("failed to read hook: %w", err)
+	}
+	content := string(data)
+
+	switch state := inspect(content, name); state {
+	case Installed, Chained:
+		start, end, _ := findBlock(content, name)
+		return state, write(path, content[:start]+block(name, body)+content[end:])
+	case Legacy:
+		if err := os.Remove(path); err != nil {
+			return state, fmt.Errorf("failed to remove old hook: %w", err)
+		}
+		if _, err := os.Stat(path + legacySuffix); err == nil {
+			if err := os.Rename(path+legacySuffix, path); err != nil {
+				return state, fmt.Errorf("failed to restore backup hook: %w", err)
+			}
+		}
+		_, err := Install(dir, name, body)
+		return state, err
+	}
+
+	// Git ignores a hook it cannot execute; chaining would silently enable it.
+	if info.Mode()&0111 == 0 {
+		return Foreign, fmt.Errorf("%s exists but is not executable; make it executable or remove it first", path)
+	}
+	if isShellScript(data) {
+		shebang, rest := "", content
+		if strings.HasPrefix(content, "#!") {
+			line, after, _ := strings.Cut(content, "\n")
+			shebang, rest = line+"\n", after
+		}
+		return Foreign, write(path, shebang+block(name, body)+"\n"+rest)
+	}
+
+	orig := path + OrigSuffix
+	if _, err := os.Stat(orig); err == nil {
+		return Foreign, fmt.Errorf("%s already exists; move it away first", orig)
+	}
+	if err := os.Rename(path, orig); err != nil {
+		return Foreign, fmt.Errorf("failed to move existing hook: %w", err)
+	}
+	dispatcher := "#!/bin/sh\n" +
+		dispatcherLine + "; it was moved to " + name + OrigSuffix + ".\n" +
+		"\"$0" + OrigSuffix + "\" \"$@\" || exit $?\n\n"
+	return Foreign, write(path, dispatcher+block(name, body))
+}
+
+// ErrNotInstalled is returned by Uninstall for a hook without an aigit
+// block.
+var ErrNotInstalled = errors.New("hook was not installed by aigit")
+
+// Uninstall removes the aigit block from the named hook in dir and returns
+// the state the hook was in. The hook is deleted when nothing else is left
+// in it, and a hook moved aside for a dispatcher or by an older aigit
+// version is put back.
+func Uninstall(dir, name string) (State, error) {
+	path := filepath.Join(dir, name)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Missing, nil
+	}
+	if err != nil {
+		return Missing, fmt.Errorf("failed to read hook: %w", err)
+	}
+	content := string(data)
+
+	state := inspect(content, name)
+	switch state {
+	case Foreign:
+		return state, ErrNotInstalled
+	case Legacy:
+		return state, remove(path, path+legacySuffix)
+	}
+
+	start, end, _ := findBlock(content, name)
+	rest := content[:start] + strings.TrimPrefix(content[end:], "\n")
+	switch {
+	case strings.Contains(rest, dispatcherLine):
+		return state, remove(path, path+OrigSuffix)
+	case onlyShebang(rest):
+		return state, remove(path, "")
+	default:
+		return state, write(path, rest)
+	}
+}
+
+// remove deletes the hook at path and moves the hook at saved, if any, back
+// in its place.
+func remove(path, saved string) error {
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove hook: %w", err)
+	}
+	if saved == "" {
+		return nil
+	}
+	if _, err := os.Stat(saved); err != nil {
+		return nil
+	}
+	if err := os.Rename(saved, path); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", filepath.Base(saved), err)
+	}
+	return nil
+}
+
+func write(path, content string) error {
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		return fmt.Errorf("failed to write hook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	hookName = "pre-commit"
+	body     = "aigit review --staged --hook"
+)
+
+func writeHook(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readHook(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func assertMissing(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s exists, want it removed", filepath.Base(path))
+	}
+}
+
+func assertState(t *testing.T, dir string, want State) {
+	t.Helper()
+	state, executable, err := Inspect(dir, hookName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != want {
+		t.Errorf("Inspect() state = %v, want %v", state, want)
+	}
+	if state != Missing && !executable {
+		t.Error("Inspect() reports the hook as not executable")
+	}
+}
+
+func TestInstallNewHook(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, hookName)
+
+	state, err := Install(dir, hookName, body)
+	if err != nil || state != Missing {
+		t.Fatalf("Install() = %v, %v; want %v, nil", state, err, Missing)
+	}
+	if got, want := readHook(t, path), "#!/bin/sh\n"+block(hookName, body); got != want {
+		t.Errorf("hook = %q, want %q", got, want)
+	}
+	assertState(t, dir, Installed)
+
+	// Reinstalling replaces the block instead of adding a second one.
+	state, err = Install(dir, hookName, "aigit review --staged --hook --quiet")
+	if err != nil || state != Installed {
+		t.Fatalf("reinstall = %v, %v; want %v, nil", state, err, Installed)
+	}
+	got := readHook(t, path)
+	if n := strings.Count(got, beginMarker(hookName)); n != 1 {
+		t.Errorf("hook has %d aigit blocks after reinstall, want 1", n)
+	}
+	if !strings.Contains(got, "--quiet") {
+		t.Errorf("reinstall kept the old body:\n%s", got)
+	}
+
+	state, err = Uninstall(dir, hookName)
+	if err != nil || state != Installed {
+		t.Fatalf("Uninstall() = %v, %v; want %v, nil", state, err, Installed)
+	}
+	assertMissing(t, path)
+	assertState(t, dir, Missing)
+}
+
+func TestInstallShellHook(t *testing.T) {
+	for _, original := range []string{
+		"#!/bin/bash\nset -e\nmake lint\n",
+		"#!/usr/bin/env -S zsh -f\nmake lint\n",
+		"make lint\n", // no shebang: git runs it with /bin/sh
+	} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, hookName)
+		writeHook(t, path, original, 0755)
+		assertState(t, dir, Foreign)
+
+		state, err := Install(dir, hookName, body)
+		if err != nil || state != Foreign {
+			t.Fatalf("Install() = %v, %v; want %v, nil", state, err, Foreign)
+		}
+		got := readHook(t, path)
+		if shebang, _, _ := strings.Cut(original, "\n"); strings.HasPrefix(shebang, "#!") && !strings.HasPrefix(got, shebang+"\n"+beginMarker(hookName)) {
+			t.Errorf("block is not right after the shebang:\n%s", got)
+		}
+		if !strings.Contains(got, "make lint\n") {
+			t.Errorf("existing hook code was lost:\n%s", got)
+		}
+		assertState(t, dir, Chained)
+
+		if state, err := Install(dir, hookName, body); err != nil || state != Chained {
+			t.Fatalf("reinstall = %v, %v; want %v, nil", state, err, Chained)
+		}
+		if state, err := Uninstall(dir, hookName); err != nil || state != Chained {
+			t.Fatalf("Uninstall() = %v, %v; want %v, nil", state, err, Chained)
+		}
+		if got := readHook(t, path); got != original {
+			t.Errorf("after uninstall hook = %q, want the original %q", got, original)
+		}
+	}
+}
+
+func TestInstallDispatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, hookName)
+	orig := path + OrigSuffix
+	const original = "#!/usr/bin/env python3\nprint('checking')\n"
+	writeHook(t, path, original, 0755)
+
+	state, err := Install(dir, hookName, body)
+	if err != nil || state != Foreign {
+		t.Fatalf("Install() = %v, %v; want %v, nil", state, err, Foreign)
+	}
+	if got := readHook(t, orig); got != original {
+		t.Errorf("%s = %q, want the original hook", filepath.Base(orig), got)
+	}
+	got := readHook(t, path)
+	if !strings.Contains(got, dispatcherLine) || !strings.Contains(got, `"$0`+OrigSuffix+`" "$@"`) {
+		t.Errorf("hook does not dispatch to the original:\n%s", got)
+	}
+	assertState(t, dir, Chained)
+
+	if state, err := Install(dir, hookName, body); err != nil || state != Chained {
+		t.Fatalf("reinstall = %v, %v; want %v, nil", state, err, Chained)
+	}
+	if got := readHook(t, orig); got != original {
+		t.Errorf("reinstall changed %s to %q", filepath.Base(orig), got)
+	}
+
+	if state, err := Uninstall(dir, hookName); err != nil || state != Chained {
+		t.Fatalf("Uninstall() = %v, %v; want %v, nil", state, err, Chained)
+	}
+	if got := readHook(t, path); got != original {
+		t.Errorf("after uninstall hook = %q, want the original %q", got, original)
+	}
+	assertMissing(t, orig)
+}
+
+func TestInstallRefusals(t *testing.T) {
+	t.Run("not executable", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, hookName)
+		writeHook(t, path, "#!/bin/sh\nmake lint\n", 0644)
+		if _, err := Install(dir, hookName, body); err == nil {
+			t.Error("Install() enabled a hook git would not run")
+		}
+		if got := readHook(t, path); got != "#!/bin/sh\nmake lint\n" {
+			t.Errorf("hook was changed to %q", got)
+		}
+	})
+
+	t.Run("original already moved aside", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, hookName)
+		writeHook(t, path, "#!/usr/bin/env node\n", 0755)
+		writeHook(t, path+OrigSuffix, "#!/usr/bin/env node\n", 0755)
+		if _, err := Install(dir, hookName, body); err == nil {
+			t.Errorf("Install() overwrote %s", OrigSuffix)
+		}
+	})
+}
+
+func TestLegacyHook(t *testing.T) {
+	t.Run("install", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, hookName)
+		writeHook(t, path, legacyPreCommit, 0755)
+		writeHook(t, path+legacySuffix, "#!/bin/sh\nmake lint\n", 0755)
+		assertState(t, dir, Legacy)
+
+		state, err := Install(dir, hookName, body)
+		if err != nil || state != Legacy {
+			t.Fatalf("Install() = %v, %v; want %v, nil", state, err, Legacy)
+		}
+		got := readHook(t, path)
+		if strings.Contains(got, "# aigit pre-commit hook") {
+			t.Errorf("old script was kept:\n%s", got)
+		}
+		if !strings.Contains(got, "make lint\n") {
+			t.Errorf("backed-up hook was not chained:\n%s", got)
+		}
+		assertMissing(t, path+legacySuffix)
+		assertState(t, dir, Chained)
+	})
+
+	t.Run("uninstall", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, hookName)
+		writeHook(t, path, legacyPreCommit, 0755)
+		writeHook(t, path+legacySuffix, "#!/bin/sh\nmake lint\n", 0755)
+		if state, err := Uninstall(dir, hookName); err != nil || state != Legacy {
+			t.Fatalf("Uninstall() = %v, %v; want %v, nil", state, err, Legacy)
+		}
+		if got := readHook(t, path); got != "#!/bin/sh\nmake lint\n" {
+			t.Errorf("backup was not restored, hook = %q", got)
+		}
+		assertMissing(t, path+legacySuffix)
+	})
+}
+
+func TestEditedLegacyHook(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, hookName)
+	edited := legacyPreCommit + "\n# team addition\nmake lint\n"
+	writeHook(t, path, edited, 0755)
+	assertState(t, dir, Foreign)
+
+	if _, err := Uninstall(dir, hookName); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("Uninstall() error = %v, want ErrNotInstalled", err)
+	}
+	if got := readHook(t, path); got != edited {
+		t.Fatalf("Uninstall() changed an edited hook")
+	}
+
+	state, err := Install(dir, hookName, body)
+	if err != nil || state != Foreign {
+		t.Fatalf("Install() = %v, %v; want %v, nil", state, err, Foreign)
+	}
+	got := readHook(t, path)
+	if !strings.Contains(got, "# team addition\nmake lint\n") {
+		t.Errorf("Install() dropped the user's lines:\n%s", got)
+	}
+	assertState(t, dir, Chained)
+
+	if _, err := Uninstall(dir, hookName); err != nil {
+		t.Fatal(err)
+	}
+	if got := readHook(t, path); got != edited {
+		t.Errorf("after uninstall hook = %q, want the edited hook back", got)
+	}
+}
+
+func TestUninstallMissing(t *testing.T) {
+	state, err := Uninstall(t.TempDir(), hookName)
+	if err != nil || state != Missing {
+		t.Errorf("Uninstall() = %v, %v; want %v, nil", state, err, Missing)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    State
+	}{
+		{"only the block", "#!/bin/sh\n" + block(hookName, body), Installed},
+		{"block of another hook", "#!/bin/sh\n" + block("commit-msg", body), Foreign},
+		{"block and other code", "#!/bin/sh\n" + block(hookName, body) + "\nmake lint\n", Chained},
+		{"dispatcher", "#!/bin/sh\n" + dispatcherLine + "\n\"$0.aigit-orig\" \"$@\" || exit $?\n\n" + block(hookName, body), Chained},
+		{"legacy script", legacyPreCommit, Legacy},
+		{"legacy header with more lines", "#!/bin/sh\n# aigit pre-commit hook - auto review code before commit\nmake lint\n", Foreign},
+		{"unrelated hook", "#!/bin/sh\nmake lint\n", Foreign},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inspect(tt.content, hookName); got != tt.want {
+				t.Errorf("inspect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
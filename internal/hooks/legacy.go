@@ -0,0 +1,22 @@
+package hooks
+
+// isLegacy reports whether content is the pre-commit hook released aigit
+// versions wrote whole, before hooks used marked blocks. Only an unmodified
+// copy counts; a copy the user has edited is chained like any other hook.
+func isLegacy(content, name string) bool {
+	return name == "pre-commit" && content == legacyPreCommit
+}
+
+const legacyPreCommit = `#!/bin/sh
+# aigit pre-commit hook - auto review code before commit
+
+echo "Running aigit code review..."
+aigit review --staged --hook
+
+if [ $? -ne 0 ]; then
+    echo ""
+    echo "Code review found issues. Commit aborted."
+    echo "Use 'git commit --no-verify' to skip this check."
+    exit 1
+fi
+`
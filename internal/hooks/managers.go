@@ -0,0 +1,72 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Hook managers aigit recognises.
+const (
+	Husky     = "husky"
+	PreCommit = "pre-commit"
+	Lefthook  = "lefthook"
+)
+
+// Manager is a tool that owns a repository's hook scripts and would
+// overwrite a hook aigit writes.
+type Manager struct {
+	Name   string
+	Config string // file or directory holding its configuration, relative to the repository root
+}
+
+var lefthookConfigs = []string{"lefthook.yml", ".lefthook.yml", "lefthook.yaml", ".lefthook.yaml"}
+
+// DetectManagers returns the hook managers set up in the repository at
+// root, given the directory git runs hooks from.
+func DetectManagers(root, hooksDir string) []Manager {
+	var found []Manager
+	husky := filepath.Join(root, ".husky")
+	if isDir(husky) || strings.HasPrefix(hooksDir, husky+string(filepath.Separator)) || hooksDir == husky {
+		found = append(found, Manager{Name: Husky, Config: ".husky"})
+	}
+	for _, name := range []string{".pre-commit-config.yaml", ".pre-commit-config.yml"} {
+		if isFile(filepath.Join(root, name)) {
+			found = append(found, Manager{Name: PreCommit, Config: name})
+			break
+		}
+	}
+	for _, name := range lefthookConfigs {
+		if isFile(filepath.Join(root, name)) {
+			found = append(found, Manager{Name: Lefthook, Config: name})
+			break
+		}
+	}
+	return found
+}
+
+// ConfigFile returns the file the aigit command for hook belongs in,
+// relative to the repository root.
+func (m Manager) ConfigFile(hook string) string {
+	if m.Name == Husky {
+		return filepath.Join(m.Config, hook)
+	}
+	return m.Config
+}
+
+// Runs reports whether the manager's configuration for hook mentions
+// command.
+func (m Manager) Runs(root, hook, command string) bool {
+	data, err := os.ReadFile(filepath.Join(root, m.ConfigFile(hook)))
+	return err == nil && strings.Contains(string(data), command)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,222 @@
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Service is the name aigit's entries are stored under.
+const Service = "aigit"
+
+// FileEnv names a JSON file used instead of the system keyring. It exists
+// for tests and for machines without a credential store; the file is
+// written with 0600 permissions but is not encrypted.
+const FileEnv = "AIGIT_KEYRING_FILE"
+
+var ErrNotFound = errors.New("secret not found in keyring")
+
+// Store reads and writes secrets by account name.
+type Store interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+}
+
+// Default returns the file stand-in when AIGIT_KEYRING_FILE is set, and
+// otherwise the platform store: the macOS Keychain, or the Secret Service
+// (GNOME Keyring, KWallet) through secret-tool elsewhere.
+func Default() (Store, error) {
+	if path := os.Getenv(FileEnv); path != "" {
+		return &FileStore{Path: path}, nil
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return keychain{}, nil
+	case "windows":
+		return nil, fmt.Errorf("no keyring backend on windows; set %s or use api_key_env or api_key_cmd", FileEnv)
+	default:
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return nil, fmt.Errorf("secret-tool not found (install libsecret-tools) or set %s", FileEnv)
+		}
+		return secretService{}, nil
+	}
+}
+
+// FileStore keeps secrets in a JSON object keyed by account.
+type FileStore struct {
+	Path string
+}
+
+func (s *FileStore) load() (map[string]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	secrets := map[string]string{}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.Path, err)
+	}
+	return secrets, nil
+}
+
+func (s *FileStore) save(secrets map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.Path, data, 0600)
+}
+
+func (s *FileStore) Get(account string) (string, error) {
+	secrets, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (s *FileStore) Set(account, secret string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[account] = secret
+	return s.save(secrets)
+}
+
+func (s *FileStore) Delete(account string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[account]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, account)
+	return s.save(secrets)
+}
+
+// secretService talks to the freedesktop Secret Service via secret-tool.
+type secretService struct{}
+
+func (secretService) Get(account string) (string, error) {
+	out, err := run("", "secret-tool", "lookup", "service", Service, "account", account)
+	// secret-tool exits 1 without output when nothing matches. Any other
+	// failure, such as no D-Bus session or a locked collection, is reported
+	// as it is.
+	if exitCode(err) == 1 && !hasStderr(err) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "", ErrNotFound
+	}
+	return out, nil
+}
+
+func (secretService) Set(account, secret string) error {
+	_, err := run(secret, "secret-tool", "store", "--label", Service+" "+account, "service", Service, "account", account)
+	return err
+}
+
+func (secretService) Delete(account string) error {
+	_, err := run("", "secret-tool", "clear", "service", Service, "account", account)
+	return err
+}
+
+// keychain uses the macOS security tool.
+type keychain struct{}
+
+func (keychain) Get(account string) (string, error) {
+	out, err := run("", "security", "find-generic-password", "-s", Service, "-a", account, "-w")
+	// 44 is errSecItemNotFound; other failures, such as a locked keychain,
+	// are reported as they are.
+	if exitCode(err) == 44 {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+func (keychain) Set(account, secret string) error {
+	// -U updates an existing item instead of failing. With -w last and no
+	// value, security prompts for the password and its confirmation, which
+	// keeps the secret out of the process list.
+	_, err := run(secret+"\n"+secret+"\n", "security", "add-generic-password", "-U", "-s", Service, "-a", account, "-w")
+	return err
+}
+
+func (keychain) Delete(account string) error {
+	_, err := run("", "security", "delete-generic-password", "-s", Service, "-a", account)
+	return err
+}
+
+func run(stdin, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", &cmdError{name: name, stderr: strings.TrimSpace(stderr.String()), err: err}
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// cmdError is a failed keyring command, described by what it printed to
+// stderr when it printed anything.
+type cmdError struct {
+	name   string
+	stderr string
+	err    error
+}
+
+func (e *cmdError) Error() string {
+	if e.stderr != "" {
+		return e.name + ": " + e.stderr
+	}
+	return e.name + ": " + e.err.Error()
+}
+
+func (e *cmdError) Unwrap() error { return e.err }
+
+// exitCode returns the exit status of the command that failed with err, or
+// -1 if it did not run to an exit.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func hasStderr(err error) bool {
+	var ce *cmdError
+	return errors.As(err, &ce) && ce.stderr != ""
+}
@@ -0,0 +1,143 @@
+package keyring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "keys.json")
+	s := &FileStore{Path: path}
+
+	if _, err := s.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get on missing file: err = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Set("openai", "sk-one"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set("claude", "sk-two"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Set("openai", "sk-three"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	for account, want := range map[string]string{"openai": "sk-three", "claude": "sk-two"} {
+		got, err := s.Get(account)
+		if err != nil || got != want {
+			t.Errorf("Get(%q) = %q, %v; want %q", account, got, err, want)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("file mode = %o, want 600", mode)
+	}
+
+	if err := s.Delete("openai"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrNotFound", err)
+	}
+	if got, err := s.Get("claude"); err != nil || got != "sk-two" {
+		t.Errorf("Get(claude) after deleting openai = %q, %v", got, err)
+	}
+}
+
+func TestFileStoreCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := &FileStore{Path: path}
+	if _, err := s.Get("openai"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Get on corrupt file: err = %v, want a parse error", err)
+	}
+	if err := s.Set("openai", "sk"); err == nil {
+		t.Error("Set on corrupt file overwrote it")
+	}
+}
+
+func TestDefaultFileEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	t.Setenv(FileEnv, path)
+
+	store, err := Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	fs, ok := store.(*FileStore)
+	if !ok || fs.Path != path {
+		t.Errorf("Default() = %#v, want FileStore at %s", store, path)
+	}
+}
+
+func TestSecretServiceGet(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake secret-tool is a shell script")
+	}
+
+	tests := []struct {
+		name     string
+		script   string // body of the fake secret-tool; "" for none on PATH
+		want     string
+		notFound bool
+		wantErr  string
+	}{
+		{name: "found", script: "echo sk-secret", want: "sk-secret"},
+		{name: "no match", script: "exit 1", notFound: true},
+		{
+			name:    "no D-Bus session",
+			script:  "echo 'Cannot autolaunch D-Bus without X11 $DISPLAY' >&2; exit 1",
+			wantErr: "Cannot autolaunch D-Bus",
+		},
+		{
+			name:    "locked collection",
+			script:  "echo 'Cannot get secret of a locked object' >&2; exit 1",
+			wantErr: "locked object",
+		},
+		{name: "other exit status", script: "exit 3", wantErr: "exit status 3"},
+		{name: "missing binary", wantErr: "secret-tool"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.script != "" {
+				script := "#!/bin/sh\n" + tt.script + "\n"
+				if err := os.WriteFile(filepath.Join(dir, "secret-tool"), []byte(script), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("PATH", dir)
+
+			got, err := secretService{}.Get("openai")
+			switch {
+			case tt.notFound:
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("Get() error = %v, want ErrNotFound", err)
+				}
+			case tt.wantErr != "":
+				if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Get() error = %v, want a non-ErrNotFound error containing %q", err, tt.wantErr)
+				}
+			default:
+				if err != nil || got != tt.want {
+					t.Errorf("Get() = %q, %v; want %q", got, err, tt.want)
+				}
+			}
+		})
+	}
+}
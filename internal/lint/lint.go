@@ -0,0 +1,204 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-goll/aigit/internal/config"
+)
+
+// DefaultTypes are the conventional commit types allowed when none are
+// configured.
+var DefaultTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+const (
+	DefaultMaxSubject = 72
+	DefaultBodyWrap   = 72
+)
+
+var (
+	conventionalRe = regexp.MustCompile(`^([a-zA-Z]+)(\(([^()]*)\))?(!)?:( ?)(.*)$`)
+	typePrefixRe   = regexp.MustCompile(`^[a-zA-Z]+(\([^()]*\))?!?:\s`)
+	gitmojiCodeRe  = regexp.MustCompile(`^:[a-z0-9_+-]+:`)
+	signOffRe      = regexp.MustCompile(`(?m)^Signed-off-by: .+ <[^<>]+>$`)
+	generatedRe    = regexp.MustCompile(`^(Merge |Revert "|fixup! |squash! |amend! )`)
+)
+
+// Rules are the checks a commit message must pass.
+type Rules struct {
+	Convention string // config.StyleConventional, StyleGitmoji, StylePlain, or "" to skip
+	Types      []string
+	Scopes     []string // any scope when empty
+	MaxSubject int
+	BodyWrap   int
+	Ticket     *regexp.Regexp // nil when no ticket is required
+	SignOff    bool
+}
+
+// NewRules builds the rules for the configured lint section and the
+// repository's convention, filling in defaults for unset values.
+func NewRules(cfg config.Lint, convention string) (*Rules, error) {
+	r := &Rules{
+		Convention: convention,
+		Types:      cfg.Types,
+		Scopes:     cfg.Scopes,
+		MaxSubject: cfg.MaxSubject,
+		BodyWrap:   cfg.BodyWrap,
+		SignOff:    cfg.SignOff,
+	}
+	if len(r.Types) == 0 {
+		r.Types = DefaultTypes
+	}
+	if r.MaxSubject == 0 {
+		r.MaxSubject = DefaultMaxSubject
+	}
+	if r.BodyWrap == 0 {
+		r.BodyWrap = DefaultBodyWrap
+	}
+	if cfg.Ticket != "" {
+		re, err := regexp.Compile(cfg.Ticket)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lint.ticket: %w", err)
+		}
+		r.Ticket = re
+	}
+	return r, nil
+}
+
+// Violation is one broken rule.
+type Violation struct {
+	Line    int    // line of the message, 0 for the message as a whole
+	Rule    string // short rule name, such as "subject-length"
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Line == 0 {
+		return fmt.Sprintf("%s (%s)", v.Message, v.Rule)
+	}
+	return fmt.Sprintf("line %d: %s (%s)", v.Line, v.Message, v.Rule)
+}
+
+// Check returns the rules message breaks. The message must already be
+// cleaned up as git would store it. Messages git writes itself, such as
+// merges, reverts and fixups, are not checked.
+func (r *Rules) Check(message string) []Violation {
+	if strings.TrimSpace(message) == "" {
+		return []Violation{{Rule: "empty", Message: "message is empty"}}
+	}
+	lines := strings.Split(message, "\n")
+	subject := lines[0]
+	if generatedRe.MatchString(subject) {
+		return nil
+	}
+
+	var vs []Violation
+	add := func(line int, rule, format string, args ...any) {
+		vs = append(vs, Violation{Line: line, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(subject); n > r.MaxSubject {
+		add(1, "subject-length", "subject is %d characters, the limit is %d", n, r.MaxSubject)
+	}
+	if strings.HasSuffix(subject, ".") {
+		add(1, "subject-period", "subject ends with a period")
+	}
+
+	// A leading ticket reference is not part of the convention.
+	rest := subject
+	if r.Ticket != nil {
+		if loc := r.Ticket.FindStringIndex(rest); loc != nil && loc[0] == 0 {
+			rest = strings.TrimLeft(rest[loc[1]:], " :]")
+		}
+	}
+	for _, v := range r.checkConvention(rest) {
+		add(1, v.Rule, "%s", v.Message)
+	}
+
+	if len(lines) > 1 && lines[1] != "" {
+		add(2, "blank-line", "the subject must be followed by a blank line")
+	}
+	for i, line := range lines[1:] {
+		if n := utf8.RuneCountInString(line); n > r.BodyWrap && !unwrappable(line) {
+			add(i+2, "body-wrap", "line is %d characters, wrap the body at %d", n, r.BodyWrap)
+		}
+	}
+
+	if r.Ticket != nil && !r.Ticket.MatchString(message) {
+		add(0, "ticket", "no ticket reference matching %s", r.Ticket)
+	}
+	if r.SignOff && !signOffRe.MatchString(message) {
+		add(0, "signoff", "missing a Signed-off-by: Name <email> trailer")
+	}
+	return vs
+}
+
+// checkConvention checks a subject, without any ticket prefix, against the
+// convention. Only Rule and Message of the result are set.
+func (r *Rules) checkConvention(subject string) []Violation {
+	var vs []Violation
+	add := func(rule, format string, args ...any) {
+		vs = append(vs, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch r.Convention {
+	case config.StyleConventional:
+		m := conventionalRe.FindStringSubmatch(subject)
+		if m == nil {
+			add("conventional", "subject must look like <type>(<scope>): <description>")
+			break
+		}
+		typ, hasScope, scope, space, desc := m[1], m[2] != "", m[3], m[5], m[6]
+		if !slices.Contains(r.Types, typ) {
+			add("type", "type %q is not allowed (use: %s)", typ, strings.Join(r.Types, ", "))
+		}
+		if hasScope {
+			for _, s := range strings.Split(scope, ",") {
+				s = strings.TrimSpace(s)
+				switch {
+				case s == "":
+					add("scope", "scope is empty")
+				case len(r.Scopes) > 0 && !slices.Contains(r.Scopes, s):
+					add("scope", "scope %q is not allowed (use: %s)", s, strings.Join(r.Scopes, ", "))
+				}
+			}
+		}
+		if space == "" {
+			add("conventional", "missing a space after the colon")
+		}
+		if strings.TrimSpace(desc) == "" {
+			add("conventional", "description is empty")
+		}
+	case config.StyleGitmoji:
+		code := gitmojiCodeRe.FindString(subject)
+		rest := subject[len(code):]
+		if code == "" {
+			emoji, size := utf8.DecodeRuneInString(subject)
+			if !unicode.Is(unicode.So, emoji) && (emoji < 0x1F000 || emoji > 0x1FAFF) {
+				add("gitmoji", "subject must start with a gitmoji, such as :sparkles: or ✨")
+				break
+			}
+			rest = strings.TrimPrefix(subject[size:], "\uFE0F")
+		}
+		if strings.TrimSpace(rest) == "" {
+			add("gitmoji", "description is empty")
+		}
+	case config.StylePlain:
+		if typePrefixRe.MatchString(subject) {
+			add("plain", "subject has a type prefix, but the repository uses plain messages")
+		}
+	}
+	return vs
+}
+
+// unwrappable reports whether a body line may exceed the wrap width: URLs,
+// indented code and single long words such as paths cannot be wrapped.
+func unwrappable(line string) bool {
+	return strings.Contains(line, "://") ||
+		strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") ||
+		!strings.Contains(line, " ")
+}
@@ -0,0 +1,203 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-goll/aigit/internal/config"
+)
+
+func TestRulesCheck(t *testing.T) {
+	long := strings.Repeat("word ", 16) // 80 characters
+
+	tests := []struct {
+		name       string
+		convention string
+		cfg        config.Lint
+		message    string
+		want       []string // "<line>:<rule>" of each violation, in order
+	}{
+		{
+			name:       "valid conventional message",
+			convention: config.StyleConventional,
+			message:    "feat(cli): add lint-msg command\n\nChecks commit messages in the commit-msg hook.",
+		},
+		{
+			name:       "empty message",
+			convention: config.StyleConventional,
+			message:    "  \n",
+			want:       []string{"0:empty"},
+		},
+		{
+			name:       "git's own messages are skipped",
+			convention: config.StyleConventional,
+			message:    "Merge branch 'main' into topic.",
+		},
+		{
+			name:       "fixup is skipped",
+			convention: config.StyleConventional,
+			message:    "fixup! feat: add lint-msg command",
+		},
+		{
+			name:       "subject too long and ending with a period",
+			convention: config.StylePlain,
+			message:    "Add " + long + ".",
+			want:       []string{"1:subject-length", "1:subject-period"},
+		},
+		{
+			name:       "configured subject length",
+			convention: config.StylePlain,
+			cfg:        config.Lint{MaxSubject: 10},
+			message:    "Add the lint command",
+			want:       []string{"1:subject-length"},
+		},
+		{
+			name:       "not conventional",
+			convention: config.StyleConventional,
+			message:    "Add lint-msg command",
+			want:       []string{"1:conventional"},
+		},
+		{
+			name:       "unknown type",
+			convention: config.StyleConventional,
+			message:    "feature: add lint-msg command",
+			want:       []string{"1:type"},
+		},
+		{
+			name:       "configured types",
+			convention: config.StyleConventional,
+			cfg:        config.Lint{Types: []string{"feature"}},
+			message:    "feature: add lint-msg command",
+		},
+		{
+			name:       "breaking change marker",
+			convention: config.StyleConventional,
+			message:    "feat(api)!: drop the v1 endpoints",
+		},
+		{
+			name:       "scope not allowed",
+			convention: config.StyleConventional,
+			cfg:        config.Lint{Scopes: []string{"cli", "ai"}},
+			message:    "fix(cli, git): handle detached HEAD",
+			want:       []string{"1:scope"},
+		},
+		{
+			name:       "empty scope",
+			convention: config.StyleConventional,
+			message:    "fix(): handle detached HEAD",
+			want:       []string{"1:scope"},
+		},
+		{
+			name:       "missing space and description",
+			convention: config.StyleConventional,
+			message:    "fix:",
+			want:       []string{"1:conventional", "1:conventional"},
+		},
+		{
+			name:       "gitmoji code",
+			convention: config.StyleGitmoji,
+			message:    ":sparkles: Add lint-msg command",
+		},
+		{
+			name:       "gitmoji emoji",
+			convention: config.StyleGitmoji,
+			message:    "✨ Add lint-msg command",
+		},
+		{
+			name:       "gitmoji missing",
+			convention: config.StyleGitmoji,
+			message:    "Add lint-msg command",
+			want:       []string{"1:gitmoji"},
+		},
+		{
+			name:       "gitmoji without description",
+			convention: config.StyleGitmoji,
+			message:    ":bug:",
+			want:       []string{"1:gitmoji"},
+		},
+		{
+			name:       "plain with a type prefix",
+			convention: config.StylePlain,
+			message:    "fix: handle detached HEAD",
+			want:       []string{"1:plain"},
+		},
+		{
+			name:    "no convention",
+			message: "whatever goes",
+		},
+		{
+			name:       "body not separated by a blank line",
+			convention: config.StylePlain,
+			message:    "Handle detached HEAD\nCurrentBranch returned an error.",
+			want:       []string{"2:blank-line"},
+		},
+		{
+			name:       "body too wide",
+			convention: config.StylePlain,
+			message:    "Handle detached HEAD\n\nShort line.\n" + long,
+			want:       []string{"4:body-wrap"},
+		},
+		{
+			name:       "URLs, code and long words may exceed the wrap width",
+			convention: config.StylePlain,
+			message: "Handle detached HEAD\n\nSee https://example.com/" + strings.Repeat("x", 80) + "\n\n" +
+				"    " + long + "\n" + strings.Repeat("internal/", 10),
+		},
+		{
+			name:       "ticket prefix before the convention",
+			convention: config.StyleConventional,
+			cfg:        config.Lint{Ticket: `[A-Z]+-[0-9]+`},
+			message:    "ABC-123: feat: add lint-msg command",
+		},
+		{
+			name:       "ticket in the body",
+			convention: config.StyleConventional,
+			cfg:        config.Lint{Ticket: `[A-Z]+-[0-9]+`},
+			message:    "feat: add lint-msg command\n\nRefs ABC-123",
+		},
+		{
+			name:       "ticket missing",
+			convention: config.StyleConventional,
+			cfg:        config.Lint{Ticket: `[A-Z]+-[0-9]+`},
+			message:    "feat: add lint-msg command",
+			want:       []string{"0:ticket"},
+		},
+		{
+			name:       "sign-off present",
+			convention: config.StylePlain,
+			cfg:        config.Lint{SignOff: true},
+			message:    "Handle detached HEAD\n\nSigned-off-by: A U Thor <author@example.com>",
+		},
+		{
+			name:       "sign-off missing",
+			convention: config.StylePlain,
+			cfg:        config.Lint{SignOff: true},
+			message:    "Handle detached HEAD\n\nSigned-off-by: A U Thor",
+			want:       []string{"0:signoff"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := NewRules(tt.cfg, tt.convention)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range rules.Check(tt.message) {
+				got = append(got, fmt.Sprintf("%d:%s", v.Line, v.Rule))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRulesInvalidTicket(t *testing.T) {
+	if _, err := NewRules(config.Lint{Ticket: "("}, config.StyleConventional); err == nil {
+		t.Error("NewRules accepted an invalid ticket pattern")
+	}
+}
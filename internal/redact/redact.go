@@ -0,0 +1,335 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Mode string
+
+const (
+	ModeMask  Mode = "mask"  // replace secrets with placeholders
+	ModeBlock Mode = "block" // refuse to send a diff that contains secrets
+	ModeOff   Mode = "off"
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "":
+		return ModeMask, nil
+	case ModeMask, ModeBlock, ModeOff:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("invalid redact mode: %s (use: mask, block, off)", s)
+	}
+}
+
+// Match describes one masked secret.
+type Match struct {
+	Kind        string
+	File        string
+	Line        int // new-side line for added and context lines, old-side for removed lines
+	Placeholder string
+}
+
+func (m Match) Location() string {
+	switch {
+	case m.File == "":
+		return "(outside file diffs)"
+	case m.Line > 0:
+		return fmt.Sprintf("%s:%d", m.File, m.Line)
+	default:
+		return m.File
+	}
+}
+
+type rule struct {
+	kind string
+	re   *regexp.Regexp
+	// group is the submatch holding the secret; 0 masks the whole match.
+	group int
+	// accept, when set, filters out matches that are not secrets.
+	accept func(secret string) bool
+}
+
+const secretNames = `(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|client[_-]?secret|credentials?)`
+
+var builtinRules = []rule{
+	{kind: "private-key", re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----.*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{kind: "aws-access-key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "github-token", re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{kind: "github-token", re: regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`)},
+	{kind: "gitlab-token", re: regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}\b`)},
+	{kind: "slack-token", re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}\b`)},
+	{kind: "anthropic-key", re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{kind: "openrouter-key", re: regexp.MustCompile(`\bsk-or-v1-[a-f0-9]{32,}`)},
+	{kind: "openai-key", re: regexp.MustCompile(`\bsk-(?:proj-|svcacct-)?[A-Za-z0-9_\-]{20,}`)},
+	{kind: "google-api-key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{kind: "stripe-key", re: regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[0-9A-Za-z]{16,}\b`)},
+	{kind: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{8,}\.eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`)},
+	{kind: "url-credentials", re: regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.\-]*://[^\s:/@]+:([^\s@/]{3,})@`), group: 1},
+	// KEY=value lines in .env, shell and YAML files.
+	{
+		kind:   "assigned-secret",
+		re:     regexp.MustCompile(`(?i)^\s*(?:export\s+)?[A-Za-z0-9_.\-]*` + secretNames + `[A-Za-z0-9_.\-]*\s*[:=]\s*([^\s"'#]{8,})\s*$`),
+		group:  1,
+		accept: notAnExpression,
+	},
+	// Quoted string literals assigned to secret-looking names in code.
+	{
+		kind:   "assigned-secret",
+		re:     regexp.MustCompile(`(?i)` + secretNames + `[A-Za-z0-9_\-]*["']?\s*(?::=|=|:)\s*["']([^"'\s]{8,})["']`),
+		group:  1,
+		accept: notAnExpression,
+	},
+}
+
+// notAnExpression rejects values that read like code (os.Getenv, cfg.APIKey,
+// ${VAR}) rather than literal credentials.
+func notAnExpression(v string) bool {
+	if strings.HasPrefix(v, "${") || strings.HasPrefix(v, "$(") || strings.HasPrefix(v, "<") {
+		return false
+	}
+	if strings.ContainsAny(v, "()") {
+		return false
+	}
+	return strings.ContainsAny(v, "0123456789") || !identifierRe.MatchString(v)
+}
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	entropyCandRe   = regexp.MustCompile(`[A-Za-z0-9+/_\-]{24,}={0,2}`)
+	identifierRe    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*[,;]?$`)
+)
+
+// lockfiles are full of integrity hashes that look like random secrets, so
+// they are not checked for high-entropy strings.
+var lockfiles = map[string]bool{
+	"go.sum": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.lock": true, "Gemfile.lock": true, "poetry.lock": true, "composer.lock": true,
+}
+
+type Redactor struct {
+	rules []rule
+}
+
+// New returns a Redactor with the built-in detectors plus extra regular
+// expressions. When an extra pattern has a capturing group only the first
+// group is masked.
+func New(extraPatterns []string) (*Redactor, error) {
+	rules := append([]rule(nil), builtinRules...)
+	for _, p := range extraPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		group := 0
+		if re.NumSubexp() > 0 {
+			group = 1
+		}
+		rules = append(rules, rule{kind: "custom", re: re, group: group})
+	}
+	return &Redactor{rules: rules}, nil
+}
+
+// Redact masks secrets in a unified diff and reports what was masked. The
+// same secret always gets the same placeholder, and the number of lines is
+// preserved so line numbers stay valid.
+func (r *Redactor) Redact(diff string) (string, []Match) {
+	var matches []Match
+	var file string
+	var oldLine, newLine int
+	inHeader, inKey := false, false
+
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "diff --git ") {
+			file, inHeader, inKey = "", true, false
+			continue
+		}
+		if strings.HasPrefix(line, "@@") {
+			oldLine, newLine = hunkStarts(line)
+			inHeader = false
+			continue
+		}
+		if inHeader {
+			if p, ok := strings.CutPrefix(line, "+++ "); ok && p != "/dev/null" {
+				file = strings.TrimPrefix(p, "b/")
+			} else if p, ok := strings.CutPrefix(line, "--- "); ok && p != "/dev/null" {
+				file = strings.TrimPrefix(p, "a/")
+			}
+			continue
+		}
+
+		prefix, content := "", line
+		lineNo := 0
+		if len(line) > 0 && (line[0] == '+' || line[0] == '-' || line[0] == ' ') {
+			prefix, content = line[:1], line[1:]
+			switch prefix {
+			case "+":
+				lineNo = newLine
+				newLine++
+			case "-":
+				lineNo = oldLine
+				oldLine++
+			default:
+				lineNo = newLine
+				oldLine++
+				newLine++
+			}
+		}
+
+		at := func(kind, placeholder string) {
+			matches = append(matches, Match{Kind: kind, File: file, Line: lineNo, Placeholder: placeholder})
+		}
+
+		// Multi-line private key blocks: keep the BEGIN/END markers and mask
+		// every line in between.
+		if inKey {
+			if privateKeyEnd.MatchString(content) {
+				inKey = false
+			} else {
+				lines[i] = prefix + placeholder("private-key", content)
+			}
+			continue
+		}
+		if privateKeyBegin.MatchString(content) && !privateKeyEnd.MatchString(content) {
+			inKey = true
+			at("private-key", placeholder("private-key", content))
+			continue
+		}
+
+		lines[i] = prefix + r.redactLine(content, file, at)
+	}
+
+	return strings.Join(lines, "\n"), matches
+}
+
+func (r *Redactor) redactLine(content, file string, at func(kind, placeholder string)) string {
+	for _, ru := range r.rules {
+		content = replaceRule(content, ru, at)
+	}
+
+	if lockfiles[path.Base(file)] {
+		return content
+	}
+	return entropyCandRe.ReplaceAllStringFunc(content, func(s string) string {
+		if !looksRandom(s) {
+			return s
+		}
+		p := placeholder("high-entropy", s)
+		at("high-entropy", p)
+		return p
+	})
+}
+
+func replaceRule(content string, ru rule, at func(kind, placeholder string)) string {
+	locs := ru.re.FindAllStringSubmatchIndex(content, -1)
+	if len(locs) == 0 {
+		return content
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range locs {
+		start, end := loc[2*ru.group], loc[2*ru.group+1]
+		if start < 0 || start < last {
+			continue
+		}
+		secret := content[start:end]
+		if strings.HasPrefix(secret, "[REDACTED:") || (ru.accept != nil && !ru.accept(secret)) {
+			continue
+		}
+		p := placeholder(ru.kind, secret)
+		at(ru.kind, p)
+		b.WriteString(content[last:start])
+		b.WriteString(p)
+		last = end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// placeholderKey keys the placeholder tags. It is random per process, so a
+// tag cannot be used to brute-force a short secret offline.
+var placeholderKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("redact: failed to generate key: %v", err))
+	}
+	return key
+}()
+
+// placeholder derives a marker from the secret that is stable within the
+// process, so repeated occurrences map to the same placeholder without
+// revealing the value.
+func placeholder(kind, secret string) string {
+	mac := hmac.New(sha256.New, placeholderKey)
+	mac.Write([]byte(secret))
+	return fmt.Sprintf("[REDACTED:%s:%s]", kind, hex.EncodeToString(mac.Sum(nil)[:4]))
+}
+
+// looksRandom flags long tokens with the character mix and Shannon entropy
+// of generated credentials. Pure hex (commit ids, checksums) and identifiers
+// made only of letters are left alone.
+func looksRandom(s string) bool {
+	var lower, upper, digit bool
+	hexOnly := true
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z':
+			lower = true
+			if c > 'f' {
+				hexOnly = false
+			}
+		case c >= 'A' && c <= 'Z':
+			upper = true
+			hexOnly = false
+		case c >= '0' && c <= '9':
+			digit = true
+		default:
+			hexOnly = false
+		}
+	}
+	if hexOnly || !digit || !(lower && upper) {
+		return false
+	}
+	return shannonEntropy(s) >= 4.2
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, c := range s {
+		counts[c]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+func hunkStarts(header string) (oldStart, newStart int) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0, 0
+	}
+	parse := func(r string) int {
+		start, _, _ := strings.Cut(r[1:], ",")
+		n, _ := strconv.Atoi(start)
+		return n
+	}
+	return parse(fields[1]), parse(fields[2])
+}
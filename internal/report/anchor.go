@@ -0,0 +1,90 @@
+package report
+
+import (
+	"path"
+	"strings"
+
+	"github.com/go-goll/aigit/internal/ai"
+	"github.com/go-goll/aigit/internal/git"
+)
+
+// AnchorFindings maps each finding onto the reviewed diff: file paths are
+// matched against the diff's files, line ranges inside a hunk are clamped to
+// it, and lines the new file cannot have are dropped, leaving the finding at
+// file level. Models often report paths with a/ b/ prefixes, which code
+// scanning UIs cannot place.
+func AnchorFindings(findings []ai.Finding, files []git.FileDiff) {
+	for i := range findings {
+		f := &findings[i]
+		file := matchFile(f.File, files)
+		if file == nil {
+			continue
+		}
+		f.File = file.Path()
+		f.StartLine, f.EndLine = anchorLines(f.StartLine, f.EndLine, file.Hunks, isNewFile(file))
+	}
+}
+
+func matchFile(name string, files []git.FileDiff) *git.FileDiff {
+	name = strings.TrimSpace(name)
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "a/"), "b/")
+	name = strings.TrimPrefix(path.Clean(name), "./")
+	if name == "" || name == "." {
+		return nil
+	}
+
+	for i := range files {
+		if files[i].Path() == name || files[i].OldPath == name {
+			return &files[i]
+		}
+	}
+
+	// Fall back to a unique suffix match ("query.go" -> "internal/db/query.go").
+	var match *git.FileDiff
+	for i := range files {
+		p := files[i].Path()
+		if strings.HasSuffix(p, "/"+name) || strings.HasSuffix(name, "/"+p) {
+			if match != nil {
+				return nil
+			}
+			match = &files[i]
+		}
+	}
+	return match
+}
+
+func anchorLines(start, end int, hunks []git.Hunk, newFile bool) (int, int) {
+	if len(hunks) == 0 {
+		return start, end
+	}
+	if end < start {
+		end = start
+	}
+
+	for _, h := range hunks {
+		if start >= h.NewStart && start <= h.NewEnd() {
+			return start, min(end, h.NewEnd())
+		}
+	}
+
+	// Outside every hunk the line may well be right, pointing at unchanged
+	// code the change affects, so it is kept. Only a line the new file
+	// cannot have is dropped; the whole of a new file is in its hunks.
+	last := hunks[len(hunks)-1].NewEnd()
+	if start < 1 || (newFile && start > last) {
+		return 0, 0
+	}
+	if newFile {
+		end = min(end, last)
+	}
+	return start, end
+}
+
+func isNewFile(f *git.FileDiff) bool {
+	for _, line := range f.Header {
+		if strings.HasPrefix(line, "new file mode") {
+			return true
+		}
+	}
+	return false
+}
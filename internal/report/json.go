@@ -0,0 +1,15 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/go-goll/aigit/internal/ai"
+)
+
+// WriteJSON writes the review result as indented JSON.
+func WriteJSON(w io.Writer, result *ai.ReviewResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
@@ -0,0 +1,161 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/go-goll/aigit/internal/ai"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolURI      = "https://github.com/go-goll/aigit"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// WriteSARIF writes the review as a SARIF 2.1.0 log with one rule per
+// finding category and one result per finding.
+func WriteSARIF(w io.Writer, result *ai.ReviewResult) error {
+	driver := sarifDriver{
+		Name:           "aigit",
+		InformationURI: toolURI,
+		Rules:          []sarifRule{},
+	}
+	ruleIndex := make(map[string]int)
+	results := []sarifResult{}
+
+	for _, f := range result.Findings {
+		category := f.Category
+		if category == "" {
+			category = "general"
+		}
+		id := "aigit/" + category
+		idx, ok := ruleIndex[id]
+		if !ok {
+			idx = len(driver.Rules)
+			ruleIndex[id] = idx
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:               id,
+				Name:             ruleName(category),
+				ShortDescription: sarifMessage{Text: "AI review finding: " + category},
+			})
+		}
+
+		text := f.Title
+		if f.Explanation != "" {
+			text += "\n\n" + f.Explanation
+		}
+		if f.SuggestedFix != "" {
+			text += "\n\nSuggested fix: " + f.SuggestedFix
+		}
+
+		r := sarifResult{
+			RuleID:     id,
+			RuleIndex:  idx,
+			Level:      sarifLevel(f.Severity),
+			Message:    sarifMessage{Text: text},
+			Properties: map[string]string{"severity": string(f.Severity)},
+		}
+		if f.File != "" {
+			loc := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.File, URIBaseID: "%SRCROOT%"},
+			}
+			if f.StartLine > 0 {
+				loc.Region = &sarifRegion{StartLine: f.StartLine, EndLine: max(f.EndLine, f.StartLine)}
+			}
+			r.Locations = []sarifLocation{{PhysicalLocation: loc}}
+		}
+		results = append(results, r)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func sarifLevel(s ai.Severity) string {
+	switch s {
+	case ai.SeverityHigh:
+		return "error"
+	case ai.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func ruleName(category string) string {
+	parts := strings.Split(category, "-")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}